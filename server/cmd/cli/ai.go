@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"github.com/urfave/cli/v2"
+	"log"
+	"net/http"
+	"node/pkg/aistub"
+	"node/pkg/lifecycle"
+)
+
+// aiStubCommand 本地启动一个 OpenAI 兼容的模型替身，用于调试 [ai] 和 /ask
+func aiStubCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "ai-stub",
+		Usage: "run a local OpenAI-compatible model stand-in for development",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "addr", Value: ":9100"},
+		},
+		Action: func(ctx *cli.Context) error {
+			log.Printf("模型替身: base_url=http://localhost%s/v1", ctx.String("addr"))
+			app := lifecycle.New(shutdownTimeout)
+			app.Serve("http", &http.Server{Addr: ctx.String("addr"), Handler: aistub.New()})
+			return app.Run(ctx.Context)
+		},
+	}
+}
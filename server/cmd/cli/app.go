@@ -2,8 +2,12 @@ package cli
 
 import (
 	"github.com/urfave/cli/v2"
+	"time"
 )
 
+// shutdownTimeout 不读取配置文件的命令停止组件的时限
+const shutdownTimeout = 10 * time.Second
+
 func commonFlags() []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
@@ -31,6 +35,12 @@ func NewApp() *cli.App {
 		Commands: []*cli.Command{
 			kafkaCommand(),
 			logCommand(),
+			exportCommand(),
+			bookCommand(),
+			serveCommand(),
+			hashPasswordCommand(),
+			oidcStubCommand(),
+			aiStubCommand(),
 		},
 	}
 }
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"log"
+	"net/http"
+	"node/pkg/auth"
+	"node/pkg/lifecycle"
+	"node/pkg/oidcstub"
+	"os"
+	"strings"
+)
+
+// hashPasswordCommand 生成 [[auth.users]] 中的 password_hash，密码从参数或标准输入读取
+func hashPasswordCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "hash-password",
+		Usage:     "print the bcrypt hash of a password for [[auth.users]]",
+		ArgsUsage: "[password]",
+		Action: func(ctx *cli.Context) error {
+			password := ctx.Args().First()
+			if password == "" {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("读取密码失败: %w", err)
+				}
+				password = strings.TrimRight(line, "\r\n")
+			}
+			hash, err := auth.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			fmt.Println(hash)
+			return nil
+		},
+	}
+}
+
+// oidcStubCommand 本地启动一个 OIDC 提供方替身，用于调试 [auth.oidc]
+func oidcStubCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "oidc-stub",
+		Usage: "run a local OIDC provider stand-in for development",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "addr", Value: ":9000"},
+			&cli.StringFlag{Name: "issuer", Value: "http://localhost:9000"},
+			&cli.StringFlag{Name: "client-id", Value: "notes"},
+			&cli.StringFlag{Name: "client-secret", Value: "notes-secret"},
+			&cli.StringSliceFlag{
+				Name:  "user",
+				Usage: "name[:group,group]",
+				Value: cli.NewStringSlice("admin:editor", "guest"),
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			var users []oidcstub.User
+			for _, s := range ctx.StringSlice("user") {
+				name, groups, _ := strings.Cut(s, ":")
+				u := oidcstub.User{Name: name, Email: name + "@notes.local"}
+				if groups != "" {
+					u.Groups = strings.Split(groups, ",")
+				}
+				users = append(users, u)
+			}
+			stub := oidcstub.New(ctx.String("issuer"), ctx.String("client-id"), ctx.String("client-secret"), users...)
+			log.Printf("OIDC 替身: issuer=%s client_id=%s", stub.Issuer, stub.ClientID)
+			app := lifecycle.New(shutdownTimeout)
+			app.Serve("http", &http.Server{Addr: ctx.String("addr"), Handler: stub})
+			return app.Run(ctx.Context)
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"log"
+	"node/conf"
+	"node/pkg/lifecycle"
+	"node/pkg/notestore"
+	"node/web"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "export notes as a static site",
+		Flags: append(commonFlags(),
+			&cli.StringFlag{
+				Name:  "notes",
+				Usage: "notes directory, overrides [[server.mounts]] in the config file",
+				Value: "../../notefile",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Aliases: []string{"o"},
+				Usage:   "output directory",
+				Value:   "../output/site",
+			},
+			&cli.BoolFlag{
+				Name:  "clean",
+				Usage: "remove the output directory before exporting",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			out := ctx.String("out")
+			if ctx.Bool("clean") {
+				if err := os.RemoveAll(out); err != nil {
+					return fmt.Errorf("清理输出目录失败: %w", err)
+				}
+			}
+
+			opt := web.ExportOptions{Notes: ctx.String("notes"), Out: out}
+			if config, err := conf.Load(ctx.String("config")); err == nil {
+				opt.Bookmarks = config.Bookmarks.File
+				// 未指定 --notes 时导出配置文件中的全部挂载点
+				if !ctx.IsSet("notes") && len(config.Server.Mounts) > 0 {
+					opt.Mounts = config.Server.NoteMounts()
+				}
+			}
+
+			app := lifecycle.New(shutdownTimeout)
+			app.Go("export", func(context.Context) error {
+				start := time.Now()
+				res, err := web.Export(opt)
+				if err != nil {
+					return fmt.Errorf("导出失败: %w", err)
+				}
+				log.Printf("导出完成: %d 篇笔记, %d 个标签, 输出目录 %s, 耗时 %s", res.Notes, res.Tags, out, time.Since(start).Round(time.Millisecond))
+				return nil
+			})
+			return app.Run(ctx.Context)
+		},
+	}
+}
+
+func bookCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "book",
+		Usage: "export a notes directory as an EPUB book or a single HTML file",
+		Flags: append(commonFlags(),
+			&cli.StringFlag{
+				Name:  "notes",
+				Usage: "notes directory, overrides [[server.mounts]] in the config file",
+				Value: "../../notefile",
+			},
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "directory to export, relative to the notes root; empty for all notes",
+			},
+			&cli.StringFlag{
+				Name:  "title",
+				Usage: "book title, defaults to the directory name",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Aliases: []string{"o"},
+				Usage:   "output file, .epub or .html",
+				Value:   "../output/book.epub",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			out := ctx.String("out")
+			ext := strings.ToLower(filepath.Ext(out))
+			if ext != ".epub" && ext != ".html" {
+				return fmt.Errorf("输出文件必须是 .epub 或 .html: %s", out)
+			}
+
+			mounts := []notestore.MountOptions{{Dir: ctx.String("notes")}}
+			if !ctx.IsSet("notes") {
+				if config, err := conf.Load(ctx.String("config")); err == nil && len(config.Server.Mounts) > 0 {
+					mounts = config.Server.NoteMounts()
+				}
+			}
+			var store *notestore.Multi
+			app := lifecycle.New(shutdownTimeout)
+			app.Append(lifecycle.Hook{
+				Name: "notes",
+				Start: func(context.Context) (err error) {
+					if store, err = notestore.OpenMulti(mounts, web.StoreOptions); err != nil {
+						return fmt.Errorf("打开笔记目录失败: %w", err)
+					}
+					return nil
+				},
+				Stop: func(context.Context) error {
+					return store.Close()
+				},
+			})
+			app.Go("book", func(context.Context) error {
+				start := time.Now()
+				book, err := web.BuildBook(web.BookOptions{Dir: ctx.String("dir"), Title: ctx.String("title"), Store: store})
+				if err != nil {
+					return fmt.Errorf("导出失败: %w", err)
+				}
+				if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+					return err
+				}
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				if ext == ".epub" {
+					err = book.WriteEPUB(f)
+				} else {
+					err = book.WriteHTML(f)
+				}
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					return fmt.Errorf("导出失败: %w", err)
+				}
+				log.Printf("导出完成: 《%s》%d 篇笔记, %d 张图片, 输出 %s, 耗时 %s", book.Title, len(book.Chapters), len(book.Images), out, time.Since(start).Round(time.Millisecond))
+				return nil
+			}, "notes")
+			return app.Run(ctx.Context)
+		},
+	}
+}
@@ -1,12 +1,14 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"github.com/urfave/cli/v2"
 	"log"
 	"node/conf"
 	"node/pkg/kafkaPkg"
+	"node/pkg/lifecycle"
 )
 
 func kafkaCommand() *cli.Command {
@@ -23,30 +25,45 @@ func kafkaCommand() *cli.Command {
 
 			log.Printf("配置信息: Brokers=%v, Group=%s", config.Kafka.Brokers, config.Kafka.Group)
 
-			// 初始化 Kafka，传入配置
-			kafkaPkg.InitKafka(&config.Kafka)
 			log.Println("开始订阅 topic: kafka_topic")
 			// 传入空字符串，使用配置文件中的 Group
 			//kafkaPkg.Subscribe(ctx.Context, "kafka_topic", "group_01", Fun)
 
 			manager := kafkaPkg.NewMultiTopicConsumerManager()
-			err = manager.AddConsumer(&config.Kafka, "kafka_topic", "group_01", 2, func(msg *kafka.Message) error {
-				log.Printf("[Topic1] 收到消息: %s", string(msg.Value))
-				err := Fun(msg)
-				log.Printf("收到消息: topic=%s, partition=%d, offset=%d, key=%s, value=%s err:%+v",
-					msg.Topic, msg.Partition, msg.Offset, string(msg.Key), string(msg.Value), err)
-				return nil
+			app := lifecycle.New(config.Server.ShutdownTimeout)
+			// 初始化 Kafka，传入配置
+			app.Append(lifecycle.Hook{
+				Name: "kafka",
+				Start: func(context.Context) error {
+					kafkaPkg.InitKafka(&config.Kafka)
+					return nil
+				},
+				Stop: func(context.Context) error {
+					return kafkaPkg.Close()
+				},
 			})
-			if err != nil {
-				log.Fatal("添加 topic1 消费者失败:", err)
-			}
-			manager.Start()
-
-			//quit := make(chan os.Signal, 1)
-			//signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-			//<-quit
-
-			return nil
+			app.Append(lifecycle.Hook{
+				Name:      "consumers",
+				DependsOn: []string{"kafka"},
+				Start: func(context.Context) error {
+					err := manager.AddConsumer(&config.Kafka, "kafka_topic", "group_01", 2, func(msg *kafka.Message) error {
+						log.Printf("[Topic1] 收到消息: %s", string(msg.Value))
+						err := Fun(msg)
+						log.Printf("收到消息: topic=%s, partition=%d, offset=%d, key=%s, value=%s err:%+v",
+							msg.Topic, msg.Partition, msg.Offset, string(msg.Key), string(msg.Value), err)
+						return nil
+					})
+					if err != nil {
+						return fmt.Errorf("添加 topic1 消费者失败: %w", err)
+					}
+					return manager.Start()
+				},
+				Stop: func(context.Context) error {
+					manager.StopAll()
+					return nil
+				},
+			})
+			return app.Run(ctx.Context)
 		},
 	}
 }
@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"github.com/urfave/cli/v2"
 	"log"
 	"node/conf"
+	"node/pkg/lifecycle"
 	"node/pkg/mysqlPkg"
 	"node/pkg/qlog"
 )
@@ -29,13 +31,22 @@ func logCommand() *cli.Command {
 			}
 
 			mysqlManager := mysqlPkg.NewManager(config.Mysql)
-
-			db, err := mysqlManager.GetClient("default")
-			if err != nil {
-				return fmt.Errorf("获取 mysql 客户端失败: %w", err)
-			}
-			qlog.Infof("db: %+v", db.DB.DryRun)
-			return nil
+			app := lifecycle.New(config.Server.ShutdownTimeout)
+			app.Append(lifecycle.Hook{
+				Name: "mysql",
+				Stop: func(context.Context) error {
+					return mysqlManager.Close()
+				},
+			})
+			app.Go("log", func(context.Context) error {
+				db, err := mysqlManager.GetClient("default")
+				if err != nil {
+					return fmt.Errorf("获取 mysql 客户端失败: %w", err)
+				}
+				qlog.Infof("db: %+v", db.DB.DryRun)
+				return nil
+			}, "mysql")
+			return app.Run(ctx.Context)
 		},
 	}
 }
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"log"
+	"node/conf"
+	"node/notesrv"
+	"node/pkg/lifecycle"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "run the notes server",
+		Flags: append(commonFlags(),
+			&cli.StringFlag{
+				Name:  "addr",
+				Usage: "listen address, overrides [server] addr",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			config, err := conf.Load(ctx.String("config"))
+			if err != nil {
+				return fmt.Errorf("加载配置文件失败: %w", err)
+			}
+			if addr := ctx.String("addr"); addr != "" {
+				config.Server.Addr = addr
+			}
+
+			app := lifecycle.New(config.Server.ShutdownTimeout)
+			if err := notesrv.Register(app, config); err != nil {
+				return err
+			}
+			log.Printf("启动笔记服务: addr=%s mounts=%d", config.Server.Addr, len(config.Server.NoteMounts()))
+			return app.Run(ctx.Context)
+		},
+	}
+}
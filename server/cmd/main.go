@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"node/cmd/cli"
+	"node/notesrv"
 	"os"
 )
 
@@ -25,6 +26,7 @@ commit: %s
 }
 
 func main() {
+	notesrv.Build = notesrv.BuildInfo{Version: Version, BuildTime: BuildTime, Commit: Commit}
 	app := cli.NewApp()
 	app.Version = version()
 	if err := app.Run(os.Args); err != nil {
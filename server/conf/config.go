@@ -2,7 +2,11 @@ package conf
 
 import (
 	"github.com/BurntSushi/toml"
+	"node/pkg/ai"
+	"node/pkg/auth"
+	"node/pkg/bookmarks"
 	"node/pkg/mysqlPkg"
+	"path/filepath"
 )
 
 var gConfig *Config
@@ -20,13 +24,28 @@ type DatabaseConfig struct {
 	Event MysqlDBNode `json:"event" toml:"event" yaml:"event"`
 }
 type Config struct {
-	Kafka KafkaConfig            `json:"kafka" toml:"kafka" yaml:"kafka"`
-	Mysql mysqlPkg.ManagerConfig `json:"mysql" toml:"mysql" yaml:"mysql"`
+	Kafka  KafkaConfig            `json:"kafka" toml:"kafka" yaml:"kafka"`
+	Mysql  mysqlPkg.ManagerConfig `json:"mysql" toml:"mysql" yaml:"mysql"`
+	Server ServerConfig           `json:"server" toml:"server" yaml:"server"`
+	Auth   auth.Config            `json:"auth" toml:"auth" yaml:"auth"`
+	AI     ai.Config              `json:"ai" toml:"ai" yaml:"ai"`
+
+	Bookmarks bookmarks.Config `json:"bookmarks" toml:"bookmarks" yaml:"bookmarks"`
 }
 
 func Load(configPath string) (cfg *Config, err error) {
 	gConfig = &Config{}
 	_, err = toml.DecodeFile(configPath, &gConfig)
+	if dir, e := filepath.Abs(filepath.Dir(configPath)); e == nil {
+		gConfig.Server.resolve(dir)
+		// 书签文件默认与配置文件放在同一目录
+		if gConfig.Bookmarks.File == "" {
+			gConfig.Bookmarks.File = "bookmarks.toml"
+		}
+		if !filepath.IsAbs(gConfig.Bookmarks.File) {
+			gConfig.Bookmarks.File = filepath.Join(dir, gConfig.Bookmarks.File)
+		}
+	}
 
 	return gConfig, err
 }
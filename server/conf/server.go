@@ -0,0 +1,96 @@
+package conf
+
+import (
+	"node/pkg/notestore"
+	"path/filepath"
+	"time"
+)
+
+// ServerConfig 笔记服务配置，对应 config.toml 的 [server]
+type ServerConfig struct {
+	Addr  string `json:"addr" toml:"addr"`
+	Notes string `json:"notes" toml:"notes"` // 笔记目录，相对路径相对于配置文件所在目录；配置了 mounts 时忽略
+
+	Mounts []MountConfig `json:"mounts" toml:"mounts"`
+
+	TLSCert string `json:"tls_cert" toml:"tls_cert"`
+	TLSKey  string `json:"tls_key" toml:"tls_key"`
+
+	ReadTimeout     time.Duration `json:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    time.Duration `json:"write_timeout" toml:"write_timeout"` // SSE 和运行笔记的流式响应不受此限制
+	IdleTimeout     time.Duration `json:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" toml:"shutdown_timeout"`
+
+	PageCacheMB int `json:"page_cache_mb" toml:"page_cache_mb"` // 渲染好的笔记页面缓存上限，MB
+
+	Kafka      bool   `json:"kafka" toml:"kafka"`             // 是否连接 [kafka] 中配置的 broker
+	KafkaTopic string `json:"kafka_topic" toml:"kafka_topic"` // 笔记变化事件的 topic
+
+	StudyDB       string `json:"study_db" toml:"study_db"`               // 保存复习进度的 [mysql] 数据库名称，为空时不开启复习
+	StudyNewCards int    `json:"study_new_cards" toml:"study_new_cards"` // 每人每天新学的卡片数
+}
+
+// MountConfig 挂载点，对应 [[server.mounts]]，每个挂载点是首页目录树的一个顶级目录
+type MountConfig struct {
+	Name       string `json:"name" toml:"name"`
+	Path       string `json:"path" toml:"path"`
+	ReadOnly   bool   `json:"read_only" toml:"read_only"`
+	Visibility string `json:"visibility" toml:"visibility"` // public（默认）| hidden：不出现在目录树、搜索、标签和导出中，只能通过链接访问
+}
+
+// NoteMounts 转换为 notestore 的挂载配置；没有配置 mounts 时把 notes 挂载到根目录
+func (c *ServerConfig) NoteMounts() []notestore.MountOptions {
+	if len(c.Mounts) == 0 {
+		return []notestore.MountOptions{{Dir: c.Notes}}
+	}
+	mounts := make([]notestore.MountOptions, 0, len(c.Mounts))
+	for _, m := range c.Mounts {
+		mounts = append(mounts, notestore.MountOptions{
+			Name:     m.Name,
+			Dir:      m.Path,
+			ReadOnly: m.ReadOnly,
+			Hidden:   m.Visibility == "hidden",
+		})
+	}
+	return mounts
+}
+
+// resolve 填充默认值，并把相对路径转换为相对于配置文件目录的绝对路径
+func (c *ServerConfig) resolve(dir string) {
+	if c.Addr == "" {
+		c.Addr = ":1024"
+	}
+	if c.Notes == "" {
+		c.Notes = "../notefile"
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = 30 * time.Second
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = time.Minute
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 2 * time.Minute
+	}
+	if c.PageCacheMB <= 0 {
+		c.PageCacheMB = 64
+	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = 10 * time.Second
+	}
+	if c.KafkaTopic == "" {
+		c.KafkaTopic = "note.events"
+	}
+	if c.StudyNewCards <= 0 {
+		c.StudyNewCards = 20
+	}
+	paths := []*string{&c.Notes, &c.TLSCert, &c.TLSKey}
+	for i := range c.Mounts {
+		paths = append(paths, &c.Mounts[i].Path)
+	}
+	for _, p := range paths {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package notesrv
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"node/pkg/markdown"
+	"node/pkg/notemeta"
+	"node/pkg/wikilink"
+	"node/web"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// treeRoot 与 homeText 同步更新的结构化目录树
+var treeRoot = &atomic.Value{}
+
+// TreeEntry /api/v1/tree 的节点
+type TreeEntry struct {
+	Name     string       `json:"name"`
+	Path     string       `json:"path"`
+	Type     string       `json:"type"` // dir | file
+	Size     int64        `json:"size"`
+	ModTime  time.Time    `json:"mtime,omitzero"`
+	Title    string       `json:"title,omitempty"`
+	Tags     []string     `json:"tags,omitempty"`
+	Children []*TreeEntry `json:"children,omitempty"`
+}
+
+// prune 返回只保留 depth 层子节点的副本，depth 为 1 时只包含直接子节点
+func (e *TreeEntry) prune(depth int) *TreeEntry {
+	if e.Type != "dir" {
+		return e
+	}
+	c := *e
+	c.Children = nil
+	if depth > 0 {
+		for _, child := range e.Children {
+			c.Children = append(c.Children, child.prune(depth-1))
+		}
+	}
+	return &c
+}
+
+// NoteResponse /api/v1/notes/{path} 的返回值
+type NoteResponse struct {
+	Path      string             `json:"path"`
+	Name      string             `json:"name"`
+	Size      int64              `json:"size"`
+	ModTime   time.Time          `json:"mtime"`
+	Meta      notemeta.Meta      `json:"meta"`
+	Binary    bool               `json:"binary,omitempty"`
+	Raw       string             `json:"raw,omitempty"`
+	HTML      template.HTML      `json:"html,omitempty"`
+	TOC       []markdown.Heading `json:"toc,omitempty"`
+	Links     []wikilink.Link    `json:"links,omitempty"`
+	Backlinks []web.NoteRef      `json:"backlinks,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func apiError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// treeAPI 返回目录树，path 指定子目录，depth 限制返回的层数，默认返回整棵树
+func treeAPI(w http.ResponseWriter, r *http.Request) {
+	entry, _ := treeRoot.Load().(*TreeEntry)
+	if entry == nil {
+		apiError(w, http.StatusServiceUnavailable, "tree is not ready")
+		return
+	}
+	keep := visible(r)
+	if p := strings.Trim(r.FormValue("path"), "/"); p != "" {
+		if !keep(p) {
+			apiError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+		for _, name := range strings.Split(p, "/") {
+			var next *TreeEntry
+			for _, c := range entry.Children {
+				if c.Name == name {
+					next = c
+					break
+				}
+			}
+			if next == nil {
+				apiError(w, http.StatusNotFound, "no such path: "+p)
+				return
+			}
+			entry = next
+		}
+	}
+	if authn.ACL.Restricted(currentUser(r)) {
+		entry = filterTree(entry, keep)
+	}
+	if depth, _ := strconv.Atoi(r.FormValue("depth")); depth > 0 {
+		entry = entry.prune(depth)
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// noteAPI 返回笔记原文、渲染结果和元信息；render=false 时只返回原文
+func noteAPI(w http.ResponseWriter, r *http.Request) {
+	p, err := store.Clean(r.PathValue("path"))
+	if err != nil {
+		code := storeStatus(err)
+		apiError(w, code, http.StatusText(code))
+		return
+	}
+	if !authn.ACL.Allowed(currentUser(r), p) {
+		apiError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+	b, info, err := store.ReadFile(p)
+	if err != nil {
+		code := storeStatus(err)
+		apiError(w, code, http.StatusText(code))
+		return
+	}
+
+	resp := &NoteResponse{
+		Path:      p,
+		Name:      path.Base(p),
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		Backlinks: backlinks(p, visible(r)),
+	}
+	if bytes.IndexByte(b, 0) >= 0 {
+		resp.Binary = true
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.Raw = string(b)
+	resp.Meta, _ = notemeta.Parse(p, b)
+	resp.Links = wikilink.Extract(p, b)
+	if render, err := strconv.ParseBool(r.FormValue("render")); err != nil || render {
+		data, err := web.RenderNote(web.Server, p, b, web.Server.WikiLinks(linkResolver(), p))
+		if err != nil {
+			apiError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.HTML, resp.TOC = data.HTML, data.TOC
+		if !data.IsMarkdown {
+			resp.HTML = data.Code
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,154 @@
+package notesrv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"node/pkg/auth"
+	"node/pkg/notestore"
+	"node/web"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupAPI 打开临时笔记目录并建立目录树；private 目录只有 team 组可以访问
+func setupAPI(t *testing.T) {
+	notes := t.TempDir()
+	os.MkdirAll(filepath.Join(notes, "Golang", "sub"), 0o755)
+	os.MkdirAll(filepath.Join(notes, "private"), 0o755)
+	os.WriteFile(filepath.Join(notes, "Golang", "a.md"), []byte("---\ntitle: 切片\n---\n## 扩容\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "Golang", "sub", "b.md"), []byte("见 [[Golang/a.md]]\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "Golang", "logo.png"), []byte("\x89PNG\r\n\x1a\n\x00\x00"), 0o644)
+	os.WriteFile(filepath.Join(notes, "private", "c.md"), []byte("见 [[Golang/a.md]]\n"), 0o644)
+
+	hash, err := auth.HashPassword("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := initAuth(auth.Config{
+		Users: []auth.UserConfig{{Name: "alice", PasswordHash: hash, Groups: []string{"team"}}},
+		Rules: []auth.Rule{{Prefix: "private", Allow: []string{"team"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = notestore.OpenMulti([]notestore.MountOptions{{Dir: notes}}, web.StoreOptions); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	load()
+}
+
+// serveAPI 经过认证中间件调用 h，user 不为空时以 HTTP Basic 登录
+func serveAPI(t *testing.T, h http.HandlerFunc, target, pathValue, user string, v any) int {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if pathValue != "" {
+		r.SetPathValue("path", pathValue)
+	}
+	if user != "" {
+		r.SetBasicAuth(user, "pw")
+	}
+	w := httptest.NewRecorder()
+	authn.Middleware(h).ServeHTTP(w, r)
+	if v != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code
+}
+
+func names(entries []*TreeEntry) []string {
+	var s []string
+	for _, e := range entries {
+		s = append(s, e.Name)
+	}
+	return s
+}
+
+func TestTreeAPI(t *testing.T) {
+	setupAPI(t)
+
+	var root TreeEntry
+	if code := serveAPI(t, treeAPI, "/api/v1/tree", "", "", &root); code != http.StatusOK {
+		t.Fatalf("tree: %d", code)
+	}
+	if got := names(root.Children); len(got) != 1 || got[0] != "Golang" {
+		t.Errorf("anonymous tree: %v", got)
+	}
+	if serveAPI(t, treeAPI, "/api/v1/tree", "", "alice", &root); len(root.Children) != 2 {
+		t.Errorf("team tree: %v", names(root.Children))
+	}
+
+	var dir TreeEntry
+	serveAPI(t, treeAPI, "/api/v1/tree?path=Golang/&depth=1", "", "", &dir)
+	if dir.Path != "Golang" || len(dir.Children) != 3 {
+		t.Fatalf("subtree: %+v", dir)
+	}
+	for _, c := range dir.Children {
+		if c.Name == "sub" && (c.Type != "dir" || c.Children != nil) {
+			t.Errorf("depth=1 kept grandchildren: %+v", c)
+		}
+		if c.Name == "a.md" && c.Title != "切片" {
+			t.Errorf("title: %+v", c)
+		}
+	}
+
+	if code := serveAPI(t, treeAPI, "/api/v1/tree?path=private", "", "", nil); code != http.StatusForbidden {
+		t.Errorf("private subtree: %d", code)
+	}
+	if code := serveAPI(t, treeAPI, "/api/v1/tree?path=Golang/nope", "", "", nil); code != http.StatusNotFound {
+		t.Errorf("missing subtree: %d", code)
+	}
+}
+
+func TestNoteAPI(t *testing.T) {
+	setupAPI(t)
+
+	var note NoteResponse
+	if code := serveAPI(t, noteAPI, "/api/v1/notes/Golang/a.md", "Golang/a.md", "", &note); code != http.StatusOK {
+		t.Fatalf("note: %d", code)
+	}
+	if note.Name != "a.md" || note.Meta.Title != "切片" || note.Raw == "" || note.HTML == "" || len(note.TOC) != 1 {
+		t.Errorf("note: %+v", note)
+	}
+	// 匿名用户看不到 private 中的反向链接
+	if len(note.Backlinks) != 1 || note.Backlinks[0].Path != "Golang/sub/b.md" {
+		t.Errorf("anonymous backlinks: %+v", note.Backlinks)
+	}
+	if serveAPI(t, noteAPI, "/api/v1/notes/Golang/a.md", "Golang/a.md", "alice", &note); len(note.Backlinks) != 2 {
+		t.Errorf("team backlinks: %+v", note.Backlinks)
+	}
+
+	// 返回的路径和反向链接都基于清理后的路径
+	note = NoteResponse{}
+	serveAPI(t, noteAPI, "/api/v1/notes/Golang/./a.md", "Golang/./a.md", "", &note)
+	if note.Path != "Golang/a.md" || len(note.Backlinks) != 1 {
+		t.Errorf("uncleaned path: %+v", note)
+	}
+
+	note = NoteResponse{}
+	serveAPI(t, noteAPI, "/api/v1/notes/Golang/a.md?render=false", "Golang/a.md", "", &note)
+	if note.Raw == "" || note.HTML != "" || note.TOC != nil {
+		t.Errorf("render=false: %+v", note)
+	}
+
+	note = NoteResponse{}
+	serveAPI(t, noteAPI, "/api/v1/notes/Golang/logo.png", "Golang/logo.png", "", &note)
+	if !note.Binary || note.Raw != "" || note.HTML != "" {
+		t.Errorf("binary: %+v", note)
+	}
+
+	for p, want := range map[string]int{
+		"private/c.md":   http.StatusForbidden,
+		"Golang/nope":    http.StatusNotFound,
+		"../config.toml": http.StatusForbidden,
+	} {
+		if code := serveAPI(t, noteAPI, "/api/v1/notes/x", p, "", nil); code != want {
+			t.Errorf("%s: %d, want %d", p, code, want)
+		}
+	}
+	if code := serveAPI(t, noteAPI, "/api/v1/notes/private/c.md", "private/c.md", "alice", nil); code != http.StatusOK {
+		t.Errorf("team private note: %d", code)
+	}
+}
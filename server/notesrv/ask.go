@@ -0,0 +1,184 @@
+package notesrv
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"node/pkg/ai"
+	"node/pkg/lifecycle"
+	"node/pkg/markdown"
+	"node/pkg/rag"
+	"node/web"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	chunkRunes = 800 // 单个段落的最大字符数
+	embedBatch = 32
+)
+
+var (
+	askTpl *template.Template
+	// asker 未配置 [ai] 时为空，/ask 显示未开启
+	asker    *rag.Asker
+	askIndex *rag.Index
+	// askSlots 同时进行的问答数，模型调用慢且按量计费
+	askSlots    = make(chan struct{}, 4)
+	embedSignal = make(chan struct{}, 1)
+)
+
+// initAsk 配置了 [ai] 时开启问答；配置了 embedding_model 时在后台为段落计算向量
+func initAsk(app *lifecycle.App, cfg ai.Config) {
+	if !cfg.Enabled() {
+		return
+	}
+	if cfg.Sources <= 0 {
+		cfg.Sources = 5
+	}
+	client := ai.NewClient(cfg)
+	askIndex = rag.NewIndex(chunkRunes)
+	asker = &rag.Asker{Index: askIndex, Chat: client.Chat, Sources: cfg.Sources}
+	if client.CanEmbed() {
+		asker.Embed = client.Embed
+		app.Go("embed", func(ctx context.Context) error {
+			embedLoop(ctx, client)
+			return nil
+		})
+	}
+	log.Printf("ask: base_url=%s model=%s embedding_model=%s", cfg.BaseURL, cfg.Model, cfg.EmbeddingModel)
+}
+
+// indexChunks 笔记内容变化时重新切分段落
+func indexChunks(p string, b []byte) {
+	if askIndex != nil {
+		askIndex.Update(p, string(b))
+	}
+}
+
+// retainChunks 清理已删除笔记的段落，并通知后台计算新段落的向量
+func retainChunks(keep func(p string) bool) {
+	if askIndex == nil {
+		return
+	}
+	askIndex.Retain(keep)
+	select {
+	case embedSignal <- struct{}{}:
+	default:
+	}
+}
+
+// embedLoop 目录树刷新后计算新段落的向量，失败时等下一次刷新再试；ctx 取消时中断正在进行的请求
+func embedLoop(ctx context.Context, client *ai.Client) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-embedSignal:
+		}
+		embedCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		n, err := askIndex.Embed(embedCtx, client.Embed, embedBatch)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("embed.err: %v (%d chunks embedded)", err, n)
+		}
+	}
+}
+
+type AskSource struct {
+	rag.Source
+	URL string
+}
+
+type AskData struct {
+	Enabled  bool
+	Question string
+	HTML     template.HTML // 回答，[n] 链接到对应的来源
+	Sources  []AskSource
+	Error    string
+}
+
+// ask 没有 q 参数时只显示输入框
+func ask(w http.ResponseWriter, r *http.Request) {
+	data := &AskData{Enabled: asker != nil, Question: strings.TrimSpace(r.FormValue("q"))}
+	if data.Enabled && data.Question != "" {
+		answer, code, err := doAsk(w, r, data.Question)
+		if err != nil {
+			w.WriteHeader(code)
+			data.Error = err.Error()
+		} else {
+			data.HTML, data.Sources = renderAnswer(answer)
+		}
+	}
+	askTpl.Execute(w, data)
+}
+
+// askAPI GET /api/v1/ask?q=，返回回答和引用的段落
+func askAPI(w http.ResponseWriter, r *http.Request) {
+	if asker == nil {
+		apiError(w, http.StatusNotFound, "ask is not configured")
+		return
+	}
+	q := strings.TrimSpace(r.FormValue("q"))
+	if q == "" {
+		apiError(w, http.StatusBadRequest, "missing q")
+		return
+	}
+	answer, code, err := doAsk(w, r, q)
+	if err != nil {
+		apiError(w, code, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, answer)
+}
+
+// doAsk 限制并发，只检索当前用户可见的笔记；模型响应可能超过 WriteTimeout，不设写超时
+func doAsk(w http.ResponseWriter, r *http.Request, q string) (*rag.Answer, int, error) {
+	select {
+	case askSlots <- struct{}{}:
+		defer func() { <-askSlots }()
+	default:
+		return nil, http.StatusTooManyRequests, errors.New("too many questions in progress, try again later")
+	}
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	answer, err := asker.Ask(r.Context(), q, visible(r))
+	if err != nil {
+		log.Println("ask.err:", err)
+		return nil, http.StatusBadGateway, errors.New("model is unavailable")
+	}
+	return answer, http.StatusOK, nil
+}
+
+var citation = regexp.MustCompile(`\[(\d+)\]`)
+
+// renderAnswer 回答按 Markdown 渲染，[n] 链接到页面下方的来源
+func renderAnswer(a *rag.Answer) (template.HTML, []AskSource) {
+	sources := make([]AskSource, len(a.Sources))
+	for i, s := range a.Sources {
+		href := web.Server.Note(s.Path)
+		switch {
+		case strings.HasSuffix(s.Path, ".md") && s.Heading != "":
+			href += "#" + url.PathEscape(markdown.Slug(s.Heading))
+		case !strings.HasSuffix(s.Path, ".md"):
+			href += "#L" + strconv.Itoa(s.Line)
+		}
+		sources[i] = AskSource{Source: s, URL: href}
+	}
+	doc, err := markdown.Render([]byte(a.Text))
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(a.Text)), sources
+	}
+	html := citation.ReplaceAllStringFunc(string(doc.HTML), func(m string) string {
+		n, _ := strconv.Atoi(m[1 : len(m)-1])
+		if n < 1 || n > len(sources) {
+			return m
+		}
+		return `<a class="cite" href="#src-` + strconv.Itoa(n) + `">` + m + `</a>`
+	})
+	return template.HTML(html), sources
+}
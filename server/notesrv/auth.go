@@ -0,0 +1,177 @@
+package notesrv
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"node/pkg/auth"
+	"node/pkg/notemeta"
+	"node/web"
+	"strings"
+)
+
+var (
+	authn    *auth.Auth
+	loginTpl *template.Template
+)
+
+// initAuth 配置 [auth]；设置了 NOTE_EDITOR_USER 时作为 editor 组的本地用户加入
+func initAuth(cfg auth.Config) error {
+	var err error
+	if authn, err = auth.New(cfg); err != nil {
+		return err
+	}
+	if editUser != "" {
+		hash, err := auth.HashPassword(editPassword)
+		if err != nil {
+			return err
+		}
+		local, err := auth.NewLocal([]auth.UserConfig{{Name: editUser, PasswordHash: hash, Groups: []string{"editor"}}})
+		if err != nil {
+			return err
+		}
+		authn.Passwords = append(authn.Passwords, local)
+	}
+	return nil
+}
+
+func currentUser(r *http.Request) *auth.User {
+	return auth.UserFrom(r.Context())
+}
+
+// visible 返回判断当前用户能否访问某个笔记的函数
+func visible(r *http.Request) func(p string) bool {
+	u := currentUser(r)
+	return func(p string) bool {
+		return authn.ACL.Allowed(u, p)
+	}
+}
+
+// checkAccess 没有权限时返回 403
+func checkAccess(w http.ResponseWriter, r *http.Request, p string) bool {
+	if cp, err := store.Clean(p); err == nil {
+		p = cp
+	}
+	if !authn.ACL.Allowed(currentUser(r), p) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// visibleTree 当前用户可见的目录树，没有被规则限制时直接使用缓存
+func visibleTree(r *http.Request) template.HTML {
+	u := currentUser(r)
+	if !authn.ACL.Restricted(u) {
+		return homeText.Load().(template.HTML)
+	}
+	root, _ := treeRoot.Load().(*TreeEntry)
+	if root == nil {
+		return ""
+	}
+	w := &strings.Builder{}
+	writeTree(w, root.Children, visible(r))
+	return template.HTML(w.String())
+}
+
+func writeTree(w *strings.Builder, entries []*TreeEntry, keep func(string) bool) {
+	for _, e := range entries {
+		if !keep(e.Path) {
+			continue
+		}
+		if e.Type == "dir" {
+			children := &strings.Builder{}
+			writeTree(children, e.Children, keep)
+			web.WriteDir(w, e.Path, e.Name, children.String())
+		} else {
+			web.WriteFile(w, web.Server, e.Path, e.Name, notemeta.Meta{Title: e.Title, Tags: e.Tags})
+		}
+	}
+}
+
+// filterTree 去掉当前用户不可见的节点，返回副本
+func filterTree(e *TreeEntry, keep func(string) bool) *TreeEntry {
+	if e.Type != "dir" {
+		return e
+	}
+	c := *e
+	c.Children = nil
+	for _, child := range e.Children {
+		if keep(child.Path) {
+			c.Children = append(c.Children, filterTree(child, keep))
+		}
+	}
+	return &c
+}
+
+type LoginData struct {
+	Next     string
+	Password bool // 是否可以用户名密码登录
+	OIDC     bool
+	Error    string
+}
+
+// safeNext 登录后只能跳转到本站的路径
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func loginPage(w http.ResponseWriter, r *http.Request) {
+	loginTpl.Execute(w, &LoginData{Next: safeNext(r.FormValue("next")), Password: len(authn.Passwords) > 0, OIDC: authn.OIDC != nil})
+}
+
+// login 表单登录，成功后写入会话 cookie
+func login(w http.ResponseWriter, r *http.Request) {
+	next := safeNext(r.FormValue("next"))
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	u, ok := authn.Verify(r.FormValue("name"), r.FormValue("password"))
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		loginTpl.Execute(w, &LoginData{Next: next, Password: true, OIDC: authn.OIDC != nil, Error: "用户名或密码错误"})
+		return
+	}
+	authn.Sessions.Issue(w, r, u)
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+func logout(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	authn.Sessions.Clear(w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// oidcLogin 跳转到 OIDC 提供方登录
+func oidcLogin(w http.ResponseWriter, r *http.Request) {
+	if authn.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := authn.OIDC.Begin(w, r, safeNext(r.FormValue("next"))); err != nil {
+		log.Println("oidc.err:", err)
+		http.Error(w, "OIDC provider is unavailable", http.StatusBadGateway)
+	}
+}
+
+func oidcCallback(w http.ResponseWriter, r *http.Request) {
+	if authn.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	u, next, err := authn.OIDC.Callback(w, r)
+	if err != nil {
+		log.Println("oidc.err:", err)
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+	authn.Sessions.Issue(w, r, u)
+	http.Redirect(w, r, safeNext(next), http.StatusSeeOther)
+}
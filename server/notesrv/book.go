@@ -0,0 +1,56 @@
+package notesrv
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/url"
+	"node/web"
+	"strings"
+)
+
+// book GET /book?dir=算法&format=epub|html，把目录导出为 EPUB 或单个 HTML 文件，只包括当前用户可见的笔记
+func book(w http.ResponseWriter, r *http.Request) {
+	dir := strings.Trim(r.FormValue("dir"), "/")
+	if dir != "" {
+		cp, err := store.Clean(dir)
+		if err != nil {
+			storeError(w, r, err)
+			return
+		}
+		if dir = cp; !checkAccess(w, r, dir) {
+			return
+		}
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "epub"
+	}
+	if format != "epub" && format != "html" {
+		http.Error(w, "format must be epub or html", http.StatusBadRequest)
+		return
+	}
+
+	b, err := web.BuildBook(web.BookOptions{Dir: dir, Title: r.FormValue("title"), Keep: visible(r), Store: store})
+	if err != nil {
+		log.Println("book.err:", err)
+		storeError(w, r, err)
+		return
+	}
+	buf := &bytes.Buffer{}
+	disposition := "inline"
+	if format == "epub" {
+		err = b.WriteEPUB(buf)
+		w.Header().Set("Content-Type", "application/epub+zip")
+		disposition = "attachment"
+	} else {
+		err = b.WriteHTML(buf)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Disposition", disposition+"; filename*=UTF-8''"+url.PathEscape(b.Title+"."+format))
+	w.Write(buf.Bytes())
+}
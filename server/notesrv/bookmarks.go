@@ -0,0 +1,90 @@
+package notesrv
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"node/pkg/bookmarks"
+	"node/pkg/lifecycle"
+	"node/web"
+)
+
+var (
+	bookmarksTpl  *template.Template
+	bookmarkStore *bookmarks.Store
+)
+
+// initBookmarks 读取 [bookmarks] file；配置了 check_interval 时在后台定时检查链接能否访问
+func initBookmarks(app *lifecycle.App, cfg bookmarks.Config) error {
+	app.Append(lifecycle.Hook{
+		Name: "bookmarks",
+		Start: func(context.Context) error {
+			var err error
+			if bookmarkStore, err = bookmarks.Open(cfg.File); err != nil {
+				return err
+			}
+			log.Printf("bookmarks: file=%s categories=%d", cfg.File, len(bookmarkStore.Categories()))
+			return nil
+		},
+	})
+	if cfg.CheckInterval <= 0 {
+		return nil
+	}
+	checker, err := bookmarks.NewChecker(cfg)
+	if err != nil {
+		return err
+	}
+	app.Go("bookmarks-check", func(ctx context.Context) error {
+		return checker.Run(ctx, bookmarkStore, cfg.CheckInterval)
+	}, "bookmarks")
+	return nil
+}
+
+// bookmarksPage GET /bookmarks，editors 可以添加、删除书签
+func bookmarksPage(w http.ResponseWriter, r *http.Request) {
+	u := currentUser(r)
+	bookmarksTpl.Execute(w, &web.BookmarksData{
+		Site:       web.Server,
+		Categories: bookmarkStore.Categories(),
+		Status:     bookmarkStore.Status(),
+		Admin:      u != nil && authn.CanEdit(u),
+	})
+}
+
+// addBookmark POST /bookmarks/add
+func addBookmark(w http.ResponseWriter, r *http.Request) {
+	l := bookmarks.Link{Name: r.FormValue("name"), URL: r.FormValue("url"), Icon: r.FormValue("icon")}
+	if err := bookmarkStore.Add(r.FormValue("category"), l); err != nil {
+		bookmarkError(w, err)
+		return
+	}
+	log.Printf("bookmarks: %s added %s", currentUser(r).Name, l.URL)
+	http.Redirect(w, r, "/bookmarks", http.StatusSeeOther)
+}
+
+// removeBookmark POST /bookmarks/remove
+func removeBookmark(w http.ResponseWriter, r *http.Request) {
+	link := r.FormValue("url")
+	if err := bookmarkStore.Remove(r.FormValue("category"), link); err != nil {
+		bookmarkError(w, err)
+		return
+	}
+	log.Printf("bookmarks: %s removed %s", currentUser(r).Name, link)
+	http.Redirect(w, r, "/bookmarks", http.StatusSeeOther)
+}
+
+func bookmarkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bookmarks.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, bookmarks.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, bookmarks.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Println("bookmarks.err:", err)
+		http.Error(w, "书签保存失败，请稍后再试", http.StatusInternalServerError)
+	}
+}
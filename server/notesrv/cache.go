@@ -0,0 +1,81 @@
+package notesrv
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"node/pkg/pagecache"
+	"node/web"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// pageCache 渲染好的笔记页面，目录树刷新时整体失效：笔记内容、反向链接和链接目标是否存在都可能变化
+	pageCache *pagecache.Cache
+	// lastChange 最近一次刷新目录树的时间，页面的 Last-Modified 不早于它
+	lastChange atomic.Int64
+
+	staticOnce  sync.Once
+	staticPages map[string]*pagecache.Page
+)
+
+func invalidatePages() {
+	lastChange.Store(time.Now().Unix())
+	if pageCache != nil {
+		pageCache.Clear()
+	}
+}
+
+// renderPage 执行模板，生成可以缓存和做条件请求的页面
+func renderPage(tpl *template.Template, data any, modTime time.Time) (*pagecache.Page, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	if changed := time.Unix(lastChange.Load(), 0); changed.After(modTime) {
+		modTime = changed
+	}
+	page := pagecache.NewPage(buf.Bytes(), "text/html; charset=utf-8", modTime)
+	// 页面可能随登录状态变化，只允许浏览器缓存，每次使用前重新验证
+	page.CacheControl = "private, no-cache"
+	return page, nil
+}
+
+// static 内嵌的静态资源，启动后不会变化，第一次请求时全部载入
+func static(w http.ResponseWriter, r *http.Request) {
+	staticOnce.Do(loadStatic)
+	page, ok := staticPages[strings.TrimPrefix(r.URL.Path, "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	page.ServeHTTP(w, r)
+}
+
+func loadStatic() {
+	staticPages = make(map[string]*pagecache.Page)
+	started := time.Now()
+	fs.WalkDir(web.Assets, "static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(web.Assets, p)
+		if err != nil {
+			return err
+		}
+		ctype := mime.TypeByExtension(path.Ext(p))
+		if ctype == "" {
+			ctype = http.DetectContentType(b)
+		}
+		page := pagecache.NewPage(b, ctype, started)
+		page.CacheControl = "public, max-age=3600"
+		staticPages[p] = page
+		return nil
+	})
+}
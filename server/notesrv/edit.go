@@ -0,0 +1,311 @@
+package notesrv
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"node/pkg/diff"
+	"node/pkg/gitrepo"
+	"node/pkg/notestore"
+	"node/web"
+	"os"
+	"strings"
+)
+
+var (
+	notesRepos                   = make(map[*notestore.Mount]*gitrepo.Repo)
+	editTpl, historyTpl, diffTpl *template.Template
+	// 兼容旧的编辑账号配置，设置后作为 editor 组的本地用户
+	editUser     = os.Getenv("NOTE_EDITOR_USER")
+	editPassword = os.Getenv("NOTE_EDITOR_PASSWORD")
+	editable     bool
+)
+
+// initEdit 每个挂载点使用各自的 git 仓库，没有仓库的挂载点不能编辑；所有挂载点都不能编辑时关闭编辑
+func initEdit() {
+	for _, m := range store.Mounts() {
+		repo, err := gitrepo.Open(m.Dir)
+		if err != nil {
+			log.Printf("gitrepo.err: mount %q: %v", m.Name, err)
+			continue
+		}
+		notesRepos[m] = repo
+		editable = editable || !m.ReadOnly
+	}
+	if !editable {
+		log.Println("no writable mount with a git repository, editing disabled")
+	}
+}
+
+// mountRepo 返回虚拟路径所在挂载点的 git 仓库，以及相对挂载目录的路径
+func mountRepo(p string) (*gitrepo.Repo, string, error) {
+	m, rel, err := store.Resolve(p)
+	if err != nil {
+		return nil, "", err
+	}
+	if m == nil {
+		return nil, "", notestore.ErrForbidden
+	}
+	return notesRepos[m], rel, nil
+}
+
+// editRepo 写操作前检查挂载点可写且有 git 仓库
+func editRepo(w http.ResponseWriter, r *http.Request, p string) (*gitrepo.Repo, string, bool) {
+	repo, rel, err := mountRepo(p)
+	if err == nil && repo == nil {
+		err = notestore.ErrReadOnly
+	}
+	if err != nil {
+		storeError(w, r, err)
+		return nil, "", false
+	}
+	return repo, rel, true
+}
+
+// editAuth 编辑笔记的接口，需要开启编辑，其余同 editorAuth
+func editAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !web.Server.Edit {
+			http.Error(w, "editing is disabled", http.StatusForbidden)
+			return
+		}
+		editorAuth(next)(w, r)
+	}
+}
+
+// editorAuth 需要登录（会话或 HTTP Basic）且属于 [auth] editors，并能访问目标路径；
+// POST 请求额外校验来源，防止跨站提交
+func editorAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := currentUser(r)
+		if u == nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="notes", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if !authn.CanEdit(u) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if p := r.PathValue("path"); p != "" && !checkAccess(w, r, p) {
+			return
+		}
+		if r.Method == http.MethodPost && !sameOrigin(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func author(r *http.Request) gitrepo.Author {
+	u := currentUser(r)
+	return gitrepo.Author{Name: u.Name, Email: u.Name + "@notes.local"}
+}
+
+type EditData struct {
+	Path    string
+	Content string
+	Exists  bool
+	Error   string
+}
+
+// newNote 新建笔记：先输入路径，再跳转到编辑页
+func newNote(w http.ResponseWriter, r *http.Request) {
+	if p := strings.Trim(r.FormValue("path"), "/ "); p != "" {
+		http.Redirect(w, r, "/edit/"+url.PathEscape(p), http.StatusSeeOther)
+		return
+	}
+	editTpl.Execute(w, &EditData{})
+}
+
+func editPage(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	data := &EditData{Path: p}
+	b, _, err := store.ReadFile(p)
+	switch {
+	case err == nil:
+		data.Content = string(b)
+		data.Exists = true
+	case !errors.Is(err, notestore.ErrNotFound):
+		storeError(w, r, err)
+		return
+	}
+	editTpl.Execute(w, data)
+}
+
+// saveNote 保存笔记并提交到 git
+func saveNote(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	repo, rel, ok := editRepo(w, r, p)
+	if !ok {
+		return
+	}
+	_, statErr := store.Stat(p)
+	content := strings.ReplaceAll(r.FormValue("content"), "\r\n", "\n")
+	claimChange(r, p)
+	if err := store.WriteFile(p, []byte(content)); err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	msg := strings.TrimSpace(r.FormValue("message"))
+	if msg == "" {
+		if statErr != nil {
+			msg = "create " + p
+		} else {
+			msg = "update " + p
+		}
+	}
+	if _, err := repo.Commit(r.Context(), author(r), msg, rel); err != nil {
+		log.Println("commit.err:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		editTpl.Execute(w, &EditData{Path: p, Content: content, Exists: true, Error: "已保存，但提交失败：" + err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/view/"+url.PathEscape(p), http.StatusSeeOther)
+}
+
+func renameNote(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	to := strings.Trim(r.FormValue("to"), "/ ")
+	if !checkAccess(w, r, to) {
+		return
+	}
+	repo, rel, ok := editRepo(w, r, p)
+	if !ok {
+		return
+	}
+	claimChange(r, p, to)
+	if err := store.Rename(p, to); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			http.Error(w, "target already exists", http.StatusConflict)
+			return
+		}
+		storeError(w, r, err)
+		return
+	}
+	_, toRel, _ := store.Resolve(to)
+	if _, err := repo.Commit(r.Context(), author(r), "rename "+p+" -> "+to, rel, toRel); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/view/"+url.PathEscape(to), http.StatusSeeOther)
+}
+
+func deleteNote(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	repo, rel, ok := editRepo(w, r, p)
+	if !ok {
+		return
+	}
+	claimChange(r, p)
+	if err := store.Remove(p); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if _, err := repo.Commit(r.Context(), author(r), "delete "+p, rel); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/bookmarks", http.StatusSeeOther)
+}
+
+type HistoryData struct {
+	Path      string
+	Revisions []gitrepo.Revision
+}
+
+func history(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	if _, err := store.Clean(p); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if !checkAccess(w, r, p) {
+		return
+	}
+	repo, rel, _ := mountRepo(p)
+	if repo == nil {
+		http.Error(w, "history is unavailable", http.StatusNotFound)
+		return
+	}
+	revs, err := repo.Log(r.Context(), rel, 200)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	historyTpl.Execute(w, &HistoryData{Path: p, Revisions: revs})
+}
+
+type DiffData struct {
+	Path     string
+	From, To string
+	Rows     []diff.Row
+}
+
+// diffPage 并排对比两个版本，to 为空表示与当前文件对比
+func diffPage(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	if _, err := store.Clean(p); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if !checkAccess(w, r, p) {
+		return
+	}
+	repo, rel, _ := mountRepo(p)
+	if repo == nil {
+		http.Error(w, "history is unavailable", http.StatusNotFound)
+		return
+	}
+	revs, err := repo.Log(r.Context(), rel, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 只允许对比该文件历史中出现过的版本
+	paths := make(map[string]string, len(revs))
+	for _, rev := range revs {
+		paths[rev.Hash] = rev.Path
+	}
+
+	content := func(rev string) (string, bool) {
+		if rev == "" {
+			b, _, err := store.ReadFile(p)
+			return string(b), err == nil || errors.Is(err, notestore.ErrNotFound)
+		}
+		repoPath, ok := paths[rev]
+		if !ok {
+			return "", false
+		}
+		// 删除文件的提交中取不到内容，按空文件处理
+		b, _ := repo.Show(r.Context(), rev, repoPath)
+		return string(b), true
+	}
+
+	data := &DiffData{Path: p, From: r.FormValue("from"), To: r.FormValue("to")}
+	a, ok1 := content(data.From)
+	b, ok2 := content(data.To)
+	if !ok1 || !ok2 {
+		http.Error(w, "unknown revision", http.StatusBadRequest)
+		return
+	}
+	data.Rows = diff.SideBySide(a, b)
+	diffTpl.Execute(w, data)
+}
@@ -0,0 +1,74 @@
+package notesrv
+
+import (
+	"context"
+	"net/http"
+	"node/conf"
+	"node/pkg/health"
+	"node/pkg/kafkaPkg"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// readyTimeout /readyz 单项检查的超时时间
+const readyTimeout = 2 * time.Second
+
+// BuildInfo 编译时通过 ldflags 注入的版本信息，见 Makefile
+type BuildInfo struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"build_time"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+}
+
+// Build 由 main 在启动前设置
+var Build BuildInfo
+
+// healthz GET /healthz，进程能处理请求即返回 200，不检查依赖
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte("ok\n"))
+}
+
+// version GET /version
+func version(w http.ResponseWriter, r *http.Request) {
+	b := Build
+	b.GoVersion = runtime.Version()
+	writeJSON(w, http.StatusOK, b)
+}
+
+// readyChecks /readyz 检查 [mysql] 中的每个数据库；开启 Kafka 事件时检查 [kafka] 中的每个 broker
+func readyChecks(cfg *conf.Config) []health.Check {
+	names := make([]string, 0, len(cfg.Mysql))
+	for name := range cfg.Mysql {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var checks []health.Check
+	for _, name := range names {
+		checks = append(checks, health.Check{
+			Name: "mysql:" + name,
+			Probe: func(ctx context.Context) error {
+				return mysqlManager.Ping(ctx, name)
+			},
+		})
+	}
+	if cfg.Server.Kafka {
+		kc := cfg.Kafka
+		// 与 kafkaPkg.InitKafka 一致，未配置 broker 时使用默认配置
+		if len(kc.Brokers) == 0 {
+			kc = *conf.Default()
+		}
+		for _, addr := range kc.Brokers {
+			checks = append(checks, health.Check{
+				Name: "kafka:" + addr,
+				Probe: func(ctx context.Context) error {
+					return kafkaPkg.PingBroker(ctx, &kc, addr)
+				},
+			})
+		}
+	}
+	return checks
+}
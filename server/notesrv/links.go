@@ -0,0 +1,98 @@
+package notesrv
+
+import (
+	"html/template"
+	"net/http"
+	"node/pkg/wikilink"
+	"node/web"
+	"sort"
+	"sync"
+)
+
+var (
+	brokenLinksTpl *template.Template
+	linkMu         sync.RWMutex
+	outLinks       = make(map[string][]wikilink.Link) // 相对路径 -> 笔记中的 [[ ]] 链接
+	resolver       = wikilink.NewResolver(nil)
+)
+
+func setLinks(p string, links []wikilink.Link) {
+	linkMu.Lock()
+	defer linkMu.Unlock()
+	if len(links) == 0 {
+		delete(outLinks, p)
+		return
+	}
+	outLinks[p] = links
+}
+
+// updateLinks 目录树刷新后清理已删除笔记的链接，并按现有笔记重建解析器
+func updateLinks(notes map[string]struct{}) {
+	files := make([]string, 0, len(notes))
+	for p := range notes {
+		files = append(files, p)
+	}
+	r := wikilink.NewResolver(files)
+
+	linkMu.Lock()
+	defer linkMu.Unlock()
+	for p := range outLinks {
+		if _, ok := notes[p]; !ok {
+			delete(outLinks, p)
+		}
+	}
+	resolver = r
+}
+
+// linkResolver 当前的解析器，解析器创建后不再修改，取出后可以无锁使用
+func linkResolver() *wikilink.Resolver {
+	linkMu.RLock()
+	defer linkMu.RUnlock()
+	return resolver
+}
+
+// backlinks 链接到 p 的笔记，按路径排序
+func backlinks(p string, keep func(string) bool) []web.NoteRef {
+	linkMu.RLock()
+	froms := wikilink.Backlinks(resolver, outLinks, p)
+	linkMu.RUnlock()
+
+	refs := make([]web.NoteRef, 0, len(froms))
+	for _, from := range froms {
+		if keep(from) {
+			refs = append(refs, web.NoteRef{Path: from, Title: getMeta(from).Title})
+		}
+	}
+	return refs
+}
+
+type BrokenLink struct {
+	From string
+	Link wikilink.Link
+}
+
+// brokenLinks 列出所有指向不存在笔记的链接
+func brokenLinks(w http.ResponseWriter, r *http.Request) {
+	var list []BrokenLink
+	keep := visible(r)
+	linkMu.RLock()
+	for from, links := range outLinks {
+		if !keep(from) {
+			continue
+		}
+		for _, l := range links {
+			if _, ok := resolver.Resolve(from, l.Target); !ok {
+				list = append(list, BrokenLink{From: from, Link: l})
+			}
+		}
+	}
+	linkMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].From != list[j].From {
+			return list[i].From < list[j].From
+		}
+		return list[i].Link.Line < list[j].Link.Line
+	})
+	brokenLinksTpl.Execute(w, list)
+}
@@ -0,0 +1,118 @@
+package notesrv
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"net/http"
+	"node/conf"
+	"node/pkg/kafkaPkg"
+	"node/pkg/lifecycle"
+	"node/pkg/metrics"
+	"node/pkg/noteevent"
+	"time"
+)
+
+var (
+	// noteEvents 对比笔记前后内容生成变化事件，只在开启 Kafka 时使用
+	noteEvents *noteevent.Tracker
+	eventQueue chan *noteevent.Event
+	eventTopic string
+
+	eventQueueDepth = metrics.NewGauge("note_events_queue_depth", "Note change events waiting to be published to Kafka.")
+	eventsDropped   = metrics.NewCounter("note_events_dropped_total", "Note change events dropped because the queue was full.")
+)
+
+// initEvents 连接 [kafka]，目录树刷新时把笔记的新建、修改、删除事件发送到 topic；
+// 发送在单独的 goroutine 中进行，broker 不可用时不阻塞刷新，队列满时丢弃事件
+func initEvents(app *lifecycle.App, cfg *conf.KafkaConfig, topic string) {
+	noteEvents = noteevent.NewTracker()
+	eventQueue = make(chan *noteevent.Event, 1024)
+	eventTopic = topic
+	app.Append(lifecycle.Hook{
+		Name: "kafka",
+		Start: func(context.Context) error {
+			kafkaPkg.InitKafka(cfg)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			return kafkaPkg.Close()
+		},
+	})
+	app.Go("events", publishEvents, "kafka")
+}
+
+// observeNote 记录笔记内容，内容变化时发送事件；超过 maxIndexSize 的文件和二进制文件不跟踪
+func observeNote(p string, b []byte, modTime time.Time) {
+	if noteEvents != nil {
+		emit(noteEvents.Observe(p, b, modTime))
+	}
+}
+
+// retainNotes 已删除的笔记发送删除事件
+func retainNotes(keep func(p string) bool) {
+	if noteEvents != nil {
+		emit(noteEvents.Retain(keep)...)
+	}
+}
+
+// claimChange 编辑接口写入前登记作者，事件中带上修改人
+func claimChange(r *http.Request, paths ...string) {
+	if noteEvents == nil {
+		return
+	}
+	for _, p := range paths {
+		if cp, err := store.Clean(p); err == nil {
+			noteEvents.Claim(cp, currentUser(r).Name)
+		}
+	}
+}
+
+func emit(events ...*noteevent.Event) {
+	for _, e := range events {
+		if e == nil {
+			continue
+		}
+		select {
+		case eventQueue <- e:
+			eventQueueDepth.With().Set(float64(len(eventQueue)))
+		default:
+			eventsDropped.With().Inc()
+			log.Printf("note event dropped, queue is full: %s %s", e.Type, e.Path)
+		}
+	}
+}
+
+// publishEvents 逐个发送队列中的事件，退出前把队列中剩余的事件发完
+func publishEvents(ctx context.Context) error {
+	for {
+		select {
+		case e := <-eventQueue:
+			eventQueueDepth.With().Set(float64(len(eventQueue)))
+			publishEvent(e)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-eventQueue:
+					publishEvent(e)
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// publishEvent 以笔记路径为 key，同一笔记的事件落在同一分区，保持顺序
+func publishEvent(e *noteevent.Event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Println("note event marshal err:", err)
+		return
+	}
+	headers := []kafka.Header{{Key: "type", Value: []byte(e.Type)}}
+	if err := kafkaPkg.PublishRetry(eventTopic, []byte(e.Path), b, headers, 3); err != nil {
+		log.Printf("publish note event %s %s: %v", e.Type, e.Path, err)
+	}
+}
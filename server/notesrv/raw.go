@@ -0,0 +1,149 @@
+package notesrv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"node/web"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxUpload 上传图片的大小上限，与读取笔记的上限一致
+const maxUpload = 10 << 20
+
+// uploadTypes 允许上传的图片类型及保存时使用的扩展名，按内容识别，不信任文件名
+var uploadTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// raw 输出笔记目录中的原始文件，供 Markdown 引用图片和附件；支持 Range，视频和大 PDF 可以分段读取
+func raw(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	if !checkAccess(w, r, p) {
+		return
+	}
+	b, info, err := store.ReadFile(p)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	ctype, ok := web.AttachmentType(p)
+	if !ok {
+		ctype = http.DetectContentType(b)
+	}
+	// 笔记目录中的 HTML 按文本输出，不能以本站身份执行脚本
+	if strings.HasPrefix(ctype, "text/html") {
+		ctype = "text/plain; charset=utf-8"
+	}
+	h := w.Header()
+	h.Set("Content-Type", ctype)
+	h.Set("Cache-Control", "private, no-cache")
+	h.Set("X-Content-Type-Options", "nosniff")
+	// SVG、XML 等文件可能带脚本，放进沙箱；浏览器不在沙箱中显示 PDF
+	if ctype != "application/pdf" {
+		h.Set("Content-Security-Policy", "sandbox; default-src 'none'; img-src 'self' data:; media-src 'self'; style-src 'unsafe-inline'")
+	}
+	http.ServeContent(w, r, path.Base(p), info.ModTime(), bytes.NewReader(b))
+}
+
+// UploadResult 上传成功后返回，Markdown 使用相对笔记的地址
+type UploadResult struct {
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	Markdown string `json:"markdown"`
+}
+
+// upload 把粘贴或拖入编辑器的图片保存到笔记所在目录并提交到 git。
+// 文件名取表单的 name 字段，没有时按时间生成；同名文件已存在时加序号，不覆盖
+func upload(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload+1<<20)
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apiError(w, http.StatusRequestEntityTooLarge, "file too large")
+			return
+		}
+		apiError(w, http.StatusBadRequest, "missing file")
+		return
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, maxUpload+1))
+	if err != nil {
+		apiError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(b) > maxUpload {
+		apiError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	}
+	ext, ok := uploadTypes[http.DetectContentType(b)]
+	if !ok {
+		apiError(w, http.StatusUnsupportedMediaType, "only png, jpeg, gif and webp images can be uploaded")
+		return
+	}
+
+	dir := path.Dir(p)
+	if dir == "." {
+		dir = ""
+	}
+	name := uploadName(r.FormValue("name"), time.Now())
+	target := path.Join(dir, name+ext)
+	for i := 1; ; i++ {
+		if _, err := store.Stat(target); err != nil {
+			break
+		}
+		target = path.Join(dir, name+"-"+strconv.Itoa(i)+ext)
+	}
+	if !checkAccess(w, r, target) {
+		return
+	}
+	repo, rel, ok := editRepo(w, r, target)
+	if !ok {
+		return
+	}
+	if err := store.WriteFile(target, b); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	if _, err := repo.Commit(r.Context(), author(r), "upload "+target, rel); err != nil {
+		log.Println("commit.err:", err)
+		apiError(w, http.StatusInternalServerError, "saved but commit failed: "+err.Error())
+		return
+	}
+	base := path.Base(target)
+	writeJSON(w, http.StatusCreated, &UploadResult{
+		Path:     target,
+		URL:      web.Server.Raw(target),
+		Markdown: "![" + strings.TrimSuffix(base, ext) + "](" + url.PathEscape(base) + ")",
+	})
+}
+
+// uploadName 去掉扩展名，只保留字母、数字和 -_，空白换成 -；为空时按时间生成
+func uploadName(name string, now time.Time) string {
+	name = strings.TrimSuffix(path.Base(strings.ReplaceAll(name, "\\", "/")), path.Ext(name))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			return r
+		case unicode.IsSpace(r):
+			return '-'
+		}
+		return -1
+	}, name)
+	if name == "" {
+		name = "paste-" + now.Format("20060102-150405")
+	}
+	return name
+}
@@ -0,0 +1,121 @@
+package notesrv
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"node/pkg/pagecache"
+	"node/web"
+	"strconv"
+	"time"
+)
+
+const (
+	recentDefault = 50
+	recentMax     = 200
+)
+
+var (
+	recentTpl *template.Template
+	// knownNotes 上一次刷新时的文件集合，addedAt 服务运行期间新出现的文件及出现时间；都由 treeMu 保护
+	knownNotes map[string]struct{}
+	addedAt    = make(map[string]time.Time)
+)
+
+// trackAdded 与上一次刷新的文件集合对比，记录新增的文件；首次加载时已有的文件不算新增
+func trackAdded(seen map[string]struct{}) {
+	if knownNotes != nil {
+		now := time.Now()
+		for p := range seen {
+			if _, ok := knownNotes[p]; !ok {
+				addedAt[p] = now
+			}
+		}
+	}
+	for p := range addedAt {
+		if _, ok := seen[p]; !ok {
+			delete(addedAt, p)
+		}
+	}
+	knownNotes = seen
+}
+
+// recentNotes 当前用户可见的最近修改的 n 篇笔记，不包括图片等附件
+func recentNotes(r *http.Request, n int) []web.RecentNote {
+	root, _ := treeRoot.Load().(*TreeEntry)
+	if root == nil {
+		return nil
+	}
+	keep := visible(r)
+	var notes []web.RecentNote
+	var walk func(entries []*TreeEntry)
+	walk = func(entries []*TreeEntry) {
+		for _, e := range entries {
+			if !keep(e.Path) {
+				continue
+			}
+			if e.Type == "dir" {
+				walk(e.Children)
+				continue
+			}
+			if _, ok := web.AttachmentType(e.Path); !ok {
+				notes = append(notes, web.RecentNote{Path: e.Path, ModTime: e.ModTime})
+			}
+		}
+	}
+	walk(root.Children)
+	notes = web.Recent(notes, n)
+
+	treeMu.Lock()
+	for i := range notes {
+		_, notes[i].Added = addedAt[notes[i].Path]
+	}
+	treeMu.Unlock()
+	for i := range notes {
+		notes[i].Meta = getMeta(notes[i].Path)
+	}
+	return notes
+}
+
+// recentLimit 查询参数 n 指定条数
+func recentLimit(r *http.Request) int {
+	n, err := strconv.Atoi(r.FormValue("n"))
+	if err != nil || n <= 0 {
+		return recentDefault
+	}
+	return min(n, recentMax)
+}
+
+func recentPage(w http.ResponseWriter, r *http.Request) {
+	recentTpl.Execute(w, &web.RecentData{Site: web.Server, Notes: recentNotes(r, recentLimit(r))})
+}
+
+// recentFeed Atom 订阅，Last-Modified 为最近一次修改的时间，阅读器轮询时没有变化返回 304
+func recentFeed(w http.ResponseWriter, r *http.Request) {
+	notes := recentNotes(r, recentLimit(r))
+	buf := &bytes.Buffer{}
+	feed := &web.Feed{Title: "笔记更新", Base: baseURL(r), Self: "/recent.atom", Notes: notes}
+	if err := web.WriteAtom(buf, feed); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var modTime time.Time
+	if len(notes) > 0 {
+		modTime = notes[0].ModTime
+	}
+	page := pagecache.NewPage(buf.Bytes(), "application/atom+xml; charset=utf-8", modTime)
+	page.CacheControl = "private, no-cache"
+	page.ServeHTTP(w, r)
+}
+
+// baseURL 请求对应的站点地址，反向代理通过 X-Forwarded-Proto 传递协议
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,120 @@
+package notesrv
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"node/pkg/sandbox"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	runner *sandbox.Sandbox
+	runSem = make(chan struct{}, 2) // 同时运行的笔记数量
+)
+
+func initRun() {
+	var err error
+	if runner, err = sandbox.New(sandbox.DefaultLimits); err != nil {
+		log.Println("sandbox.err:", err, "running notes disabled")
+		runner = nil
+	}
+}
+
+// runModes Go 笔记支持的运行方式：带 main 函数的可以直接运行，所在包有测试文件的可以运行测试
+func runModes(p string, b []byte) (run, test bool) {
+	if runner == nil || path.Ext(p) != ".go" {
+		return false, false
+	}
+	if strings.HasSuffix(p, "_test.go") {
+		return false, true
+	}
+	files, _ := packageFiles(p, b)
+	for name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			test = true
+			break
+		}
+	}
+	return sandbox.Runnable(p, b), test
+}
+
+// packageFiles 笔记所在目录中与它同一个包的全部 Go 文件
+func packageFiles(p string, b []byte) (map[string][]byte, error) {
+	pkg := sandbox.PackageName(p, b)
+	dir := path.Dir(p)
+	if dir == "." {
+		dir = ""
+	}
+	entries, err := store.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{path.Base(p): b}
+	for _, d := range entries {
+		if d.IsDir() || path.Ext(d.Name()) != ".go" || d.Name() == path.Base(p) {
+			continue
+		}
+		src, _, err := store.ReadFile(path.Join(dir, d.Name()))
+		if err == nil && sandbox.PackageName(d.Name(), src) == pkg {
+			files[d.Name()] = src
+		}
+	}
+	return files, nil
+}
+
+// runNote 在沙箱中运行笔记或它所在包的测试，以 NDJSON 流的形式逐行返回输出
+func runNote(w http.ResponseWriter, r *http.Request) {
+	if runner == nil {
+		http.Error(w, "running notes is disabled", http.StatusForbidden)
+		return
+	}
+	p := r.PathValue("path")
+	b, _, err := store.ReadFile(p)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	mode := sandbox.Mode(r.FormValue("mode"))
+	run, test := runModes(p, b)
+	var files map[string][]byte
+	switch {
+	case mode == sandbox.ModeRun && run:
+		files = map[string][]byte{path.Base(p): b}
+	case mode == sandbox.ModeTest && test:
+		if files, err = packageFiles(p, b); err != nil {
+			storeError(w, r, err)
+			return
+		}
+	default:
+		http.Error(w, "note cannot be run in mode "+string(mode), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case runSem <- struct{}{}:
+		defer func() { <-runSem }()
+	default:
+		http.Error(w, "too many running notes, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	enc := json.NewEncoder(w)
+	// 页面关闭时 r.Context() 取消，沙箱随之终止进程
+	err = runner.Run(r.Context(), mode, files, func(e sandbox.Event) {
+		enc.Encode(e)
+		rc.Flush()
+	})
+	if err != nil {
+		log.Println("run.err:", p, err)
+		enc.Encode(sandbox.Event{Type: "error", Data: err.Error()})
+	}
+}
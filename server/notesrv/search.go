@@ -0,0 +1,77 @@
+package notesrv
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"node/pkg/notemeta"
+	"node/pkg/search"
+	"node/pkg/wikilink"
+	"strconv"
+	"strings"
+)
+
+const maxIndexSize = 1 << 20 // 超过 1MB 的文件不建索引
+
+var (
+	searchTpl   *template.Template
+	searchIndex = search.New()
+)
+
+// indexFile 增量更新全文索引、笔记元信息和链接，修改时间未变化的文件直接跳过
+func indexFile(p string, d fs.DirEntry) {
+	info, err := d.Info()
+	if err != nil {
+		return
+	}
+	if mt, ok := searchIndex.ModTime(p); ok && mt.Equal(info.ModTime()) {
+		return
+	}
+	if info.Size() > maxIndexSize {
+		return
+	}
+	b, _, err := store.ReadFile(p)
+	if err != nil {
+		return
+	}
+	// 跳过二进制文件
+	if strings.IndexByte(string(b), 0) >= 0 {
+		return
+	}
+	observeNote(p, b, info.ModTime())
+	indexChunks(p, b)
+	indexCards(p, b)
+	searchIndex.Update(p, info.ModTime(), string(b))
+	meta, _ := notemeta.Parse(p, b)
+	setMeta(p, meta)
+	setLinks(p, wikilink.Extract(p, b))
+}
+
+type SearchData struct {
+	Query   string          `json:"query"`
+	Total   int             `json:"total"`
+	Results []search.Result `json:"results"`
+}
+
+func doSearch(r *http.Request) *SearchData {
+	q := strings.TrimSpace(r.FormValue("q"))
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	results := searchIndex.SearchFilter(q, limit, visible(r))
+	if results == nil {
+		results = []search.Result{}
+	}
+	return &SearchData{Query: q, Total: len(results), Results: results}
+}
+
+func searchPage(w http.ResponseWriter, r *http.Request) {
+	searchTpl.Execute(w, doSearch(r))
+}
+
+func searchAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(doSearch(r))
+}
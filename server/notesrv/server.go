@@ -0,0 +1,306 @@
+package notesrv
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"html/template"
+	"log"
+	"net/http"
+	"node/conf"
+	"node/pkg/compress"
+	"node/pkg/health"
+	"node/pkg/kafkaPkg"
+	"node/pkg/lifecycle"
+	"node/pkg/metrics"
+	"node/pkg/mysqlPkg"
+	"node/pkg/notestore"
+	"node/pkg/pagecache"
+	"node/web"
+	"sync/atomic"
+)
+
+var (
+	store                   *notestore.Multi
+	mysqlManager            *mysqlPkg.Manager
+	homeTpl, viewTpl, mdTpl *template.Template
+	homeText                = &atomic.Value{}
+)
+
+// init 模板内嵌在二进制中，解析失败属于程序错误
+func init() {
+	var err error
+	if homeTpl, err = web.Parse("home.html"); err != nil {
+		panic(err)
+	}
+
+	if viewTpl, err = web.Parse("view.html"); err != nil {
+		panic(err)
+	}
+
+	if mdTpl, err = web.Parse("md.html"); err != nil {
+		panic(err)
+	}
+
+	if searchTpl, err = web.Parse("search.html"); err != nil {
+		panic(err)
+	}
+
+	if editTpl, err = web.Parse("edit.html"); err != nil {
+		panic(err)
+	}
+
+	if historyTpl, err = web.Parse("history.html"); err != nil {
+		panic(err)
+	}
+
+	if diffTpl, err = web.Parse("diff.html"); err != nil {
+		panic(err)
+	}
+
+	if tagsTpl, err = web.Parse("tags.html"); err != nil {
+		panic(err)
+	}
+
+	if brokenLinksTpl, err = web.Parse("broken-links.html"); err != nil {
+		panic(err)
+	}
+
+	if loginTpl, err = web.Parse("login.html"); err != nil {
+		panic(err)
+	}
+
+	if recentTpl, err = web.Parse("recent.html"); err != nil {
+		panic(err)
+	}
+
+	if askTpl, err = web.Parse("ask.html"); err != nil {
+		panic(err)
+	}
+
+	if studyTpl, err = web.Parse("study.html"); err != nil {
+		panic(err)
+	}
+
+	if bookmarksTpl, err = web.Parse("bookmarks.html"); err != nil {
+		panic(err)
+	}
+
+}
+
+// Register 按 [server] 配置把笔记服务的组件注册到 app：笔记目录、Kafka、MySQL、后台协程和 HTTP 服务，
+// app.Run 按依赖顺序启动，退出时按相反顺序停止
+func Register(app *lifecycle.App, cfg *conf.Config) error {
+	sc := cfg.Server
+	if err := initAuth(cfg.Auth); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	app.Append(lifecycle.Hook{
+		Name: "notes",
+		Start: func(context.Context) error {
+			return openNotes(sc)
+		},
+		Stop: func(context.Context) error {
+			return store.Close()
+		},
+	})
+	// 加载目录树时会记录笔记事件、抽取卡片，这些组件要先就绪；停止时目录树的监听先退出
+	treeDeps := []string{"notes"}
+	if sc.Kafka {
+		initEvents(app, &cfg.Kafka, sc.KafkaTopic)
+		treeDeps = append(treeDeps, "events")
+	}
+	initAsk(app, cfg.AI)
+	// [mysql] 中的数据库在第一次使用时连接，退出时关闭
+	mysqlManager = mysqlPkg.NewManager(cfg.Mysql)
+	app.Append(lifecycle.Hook{
+		Name: "mysql",
+		Stop: func(context.Context) error {
+			return mysqlManager.Close()
+		},
+	})
+	if sc.StudyDB != "" {
+		initStudy(app, sc, cfg.Mysql)
+		treeDeps = append(treeDeps, "study")
+	}
+	app.Append(lifecycle.Hook{
+		Name:      "tree",
+		DependsOn: treeDeps,
+		Start: func(context.Context) error {
+			load()
+			if noteEvents != nil {
+				noteEvents.Ready()
+			}
+			return nil
+		},
+	})
+	app.Go("watch", watch, "tree")
+	if err := initBookmarks(app, cfg.Bookmarks); err != nil {
+		return fmt.Errorf("bookmarks: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /static/", static)
+	// 书签原先是静态页面，旧地址跳转到 /bookmarks
+	mux.Handle("GET /static/sites.html", http.RedirectHandler("/bookmarks", http.StatusMovedPermanently))
+	//home
+	mux.HandleFunc("/{$}", home)
+	mux.HandleFunc("/view/{path...}", view)
+	mux.HandleFunc("GET /raw/{path...}", raw)
+	mux.HandleFunc("/search", searchPage)
+	mux.HandleFunc("/api/search", searchAPI)
+	mux.HandleFunc("GET /api/v1/search", searchAPI)
+	mux.HandleFunc("GET /api/v1/tree", treeAPI)
+	mux.HandleFunc("GET /api/v1/notes/{path...}", noteAPI)
+	mux.HandleFunc("/tree", tree)
+	mux.HandleFunc("GET /new", editAuth(newNote))
+	mux.HandleFunc("GET /edit/{path}", editAuth(editPage))
+	mux.HandleFunc("POST /edit/{path}", editAuth(saveNote))
+	mux.HandleFunc("POST /rename/{path}", editAuth(renameNote))
+	mux.HandleFunc("POST /delete/{path}", editAuth(deleteNote))
+	mux.HandleFunc("POST /run/{path}", editAuth(runNote))
+	mux.HandleFunc("POST /upload/{path}", editAuth(upload))
+	mux.HandleFunc("GET /history/{path}", history)
+	mux.HandleFunc("GET /diff/{path}", diffPage)
+	mux.HandleFunc("GET /tags", tagsPage)
+	mux.HandleFunc("GET /tags/{tag}", tagPage)
+	mux.HandleFunc("GET /broken-links", brokenLinks)
+	mux.HandleFunc("GET /recent", recentPage)
+	mux.HandleFunc("GET /recent.atom", recentFeed)
+	mux.HandleFunc("GET /ask", ask)
+	mux.HandleFunc("GET /api/v1/ask", askAPI)
+	mux.HandleFunc("GET /book", book)
+	mux.HandleFunc("GET /study", study)
+	mux.HandleFunc("POST /study/review", studyReview)
+	mux.HandleFunc("GET /bookmarks", bookmarksPage)
+	mux.HandleFunc("POST /bookmarks/add", editorAuth(addBookmark))
+	mux.HandleFunc("POST /bookmarks/remove", editorAuth(removeBookmark))
+	mux.HandleFunc("GET /login", loginPage)
+	mux.HandleFunc("POST /login", login)
+	mux.HandleFunc("POST /logout", logout)
+	mux.HandleFunc("GET /auth/oidc", oidcLogin)
+	mux.HandleFunc("GET /auth/callback", oidcCallback)
+	mux.Handle("/events", broker)
+	mux.HandleFunc("GET /healthz", healthz)
+	mux.Handle("GET /readyz", health.Handler(readyTimeout, readyChecks(cfg)))
+	mux.HandleFunc("GET /version", version)
+	mux.Handle("GET /metrics", metrics.Default)
+	if sc.Kafka {
+		mux.HandleFunc("/md/kafka", func(writer http.ResponseWriter, request *http.Request) {
+			kafkaPkg.Publish("kafka_topic", []byte("hello kafka"), []byte("hello kafka"), []kafka.Header{{Key: "type", Value: []byte("test")}})
+		})
+	}
+
+	server := &http.Server{
+		Addr:         sc.Addr,
+		Handler:      compress.Handler(authn.Middleware(metrics.Middleware(mux))),
+		ReadTimeout:  sc.ReadTimeout,
+		WriteTimeout: sc.WriteTimeout,
+		IdleTimeout:  sc.IdleTimeout,
+	}
+	if sc.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(sc.TLSCert, sc.TLSKey)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	// Shutdown 不会中断 /events 的长连接，需要主动断开
+	server.RegisterOnShutdown(broker.Close)
+	app.Serve("http", server, "tree", "bookmarks")
+	return nil
+}
+
+// openNotes 打开笔记目录；所有读取笔记的操作都经过 store，防止路径穿越读取笔记目录以外的文件
+func openNotes(sc conf.ServerConfig) error {
+	var err error
+	if store, err = notestore.OpenMulti(sc.NoteMounts(), web.StoreOptions); err != nil {
+		return err
+	}
+	for _, m := range store.Mounts() {
+		log.Printf("mount: name=%q dir=%s read_only=%t hidden=%t", m.Name, m.Dir, m.ReadOnly, m.Hidden)
+	}
+	initEdit()
+	initRun()
+	web.Server.Edit = editable && authn.Enabled()
+	pageCache = pagecache.New(sc.PageCacheMB << 20)
+	return nil
+}
+
+func home(w http.ResponseWriter, r *http.Request) {
+	data := &web.HomeData{Site: web.Server, Tree: visibleTree(r), Login: authn.Enabled()}
+	if u := currentUser(r); u != nil {
+		data.User = u.Name
+	}
+	homeTpl.Execute(w, data)
+}
+
+func view(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	if len(p) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if !checkAccess(w, r, p) {
+		return
+	}
+	// 图片、PDF 等附件不按文本显示
+	if _, ok := web.AttachmentType(p); ok {
+		http.Redirect(w, r, web.Server.Raw(p), http.StatusFound)
+		return
+	}
+	// 受访问规则限制的用户看到的反向链接不同，不使用缓存
+	cacheable := !authn.ACL.Restricted(currentUser(r))
+	if page, ok := pageCache.Get(p); ok && cacheable {
+		pageCache.Serve(w, r, p, page)
+		return
+	}
+	b, info, err := store.ReadFile(p)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+
+	data, err := web.RenderNote(web.Server, p, b, web.Server.WikiLinks(linkResolver(), p))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data.Backlinks = backlinks(p, visible(r))
+	data.Run, data.Test = runModes(p, b)
+	tpl := viewTpl
+	if data.IsMarkdown {
+		tpl = mdTpl
+	}
+	page, err := renderPage(tpl, data, info.ModTime())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if cacheable {
+		pageCache.Put(p, page)
+	}
+	pageCache.Serve(w, r, p, page)
+}
+
+// storeError 把 notestore 的错误转换为 HTTP 状态码
+func storeError(w http.ResponseWriter, r *http.Request, err error) {
+	if code := storeStatus(err); code != http.StatusNotFound {
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func storeStatus(err error) int {
+	switch {
+	case errors.Is(err, notestore.ErrForbidden), errors.Is(err, notestore.ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, notestore.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusNotFound
+	}
+}
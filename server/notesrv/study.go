@@ -0,0 +1,262 @@
+package notesrv
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"node/conf"
+	"node/pkg/flashcard"
+	"node/pkg/lifecycle"
+	"node/pkg/markdown"
+	"node/pkg/mysqlPkg"
+	"node/web"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	studyTpl *template.Template
+	// studyStore 未配置 study_db 时为空，/study 显示未开启
+	studyStore    flashcard.Store
+	studyNewCards int
+
+	cardsMu   sync.RWMutex
+	noteCards = make(map[string][]flashcard.Card)
+)
+
+// initStudy 连接 [mysql] 中名为 study_db 的数据库保存复习进度；连接失败时只关闭复习功能，
+// 配置错误（数据库不存在、没有开启 parse_time）时启动失败
+func initStudy(app *lifecycle.App, sc conf.ServerConfig, mysql mysqlPkg.ManagerConfig) {
+	app.Append(lifecycle.Hook{
+		Name:      "study",
+		DependsOn: []string{"mysql"},
+		Start: func(context.Context) error {
+			if err := checkStudyDB(sc.StudyDB, mysql); err != nil {
+				return fmt.Errorf("study: %w", err)
+			}
+			client, err := mysqlManager.GetClient(sc.StudyDB)
+			if err != nil {
+				log.Printf("study: disabled: mysql %q: %v", sc.StudyDB, err)
+				return nil
+			}
+			db, err := flashcard.NewDB(client.WithContext(context.Background()))
+			if err != nil {
+				log.Printf("study: disabled: migrate: %v", err)
+				return nil
+			}
+			studyStore = db
+			studyNewCards = sc.StudyNewCards
+			log.Printf("study: db=%s new_cards=%d", sc.StudyDB, studyNewCards)
+			return nil
+		},
+	})
+}
+
+// checkStudyDB 复习进度的时间字段需要 parseTime，study_db 的配置中必须开启 parse_time
+func checkStudyDB(name string, mysql mysqlPkg.ManagerConfig) error {
+	c := mysql[name]
+	if c == nil {
+		return fmt.Errorf("study_db %q is not configured in [mysql]", name)
+	}
+	if !c.ParsesTime() {
+		return fmt.Errorf("study_db %q: set parse_time = true in [mysql.%s]", name, name)
+	}
+	return nil
+}
+
+// indexCards 笔记内容变化时重新抽取卡片
+func indexCards(p string, b []byte) {
+	if studyStore == nil {
+		return
+	}
+	cards := flashcard.Extract(p, string(b))
+	cardsMu.Lock()
+	defer cardsMu.Unlock()
+	if len(cards) == 0 {
+		delete(noteCards, p)
+		return
+	}
+	noteCards[p] = cards
+}
+
+// retainCards 清理已删除笔记的卡片
+func retainCards(keep func(p string) bool) {
+	cardsMu.Lock()
+	defer cardsMu.Unlock()
+	for p := range noteCards {
+		if !keep(p) {
+			delete(noteCards, p)
+		}
+	}
+}
+
+// visibleCards 当前用户可见的卡片，按路径和行号排序
+func visibleCards(r *http.Request) []flashcard.Card {
+	keep := visible(r)
+	var cards []flashcard.Card
+	cardsMu.RLock()
+	for p, cs := range noteCards {
+		if keep(p) {
+			cards = append(cards, cs...)
+		}
+	}
+	cardsMu.RUnlock()
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Path != cards[j].Path {
+			return cards[i].Path < cards[j].Path
+		}
+		return cards[i].Line < cards[j].Line
+	})
+	return cards
+}
+
+type StudyDeck struct {
+	Dir string
+	flashcard.Stats
+}
+
+type StudyCard struct {
+	flashcard.Card
+	URL        string
+	AnswerHTML template.HTML
+}
+
+type StudyData struct {
+	Enabled   bool
+	Login     bool // 需要登录
+	User      string
+	Dir       string
+	Decks     []StudyDeck // 按目录统计，Dir 为空时显示
+	Stats     flashcard.Stats
+	Card      *StudyCard // 下一张要复习的卡片，为空时本次复习完成
+	Remaining int
+	Error     string
+}
+
+// study 按目录复习：显示进度统计和下一张卡片，先看问题，展开答案后按记忆程度打分
+func study(w http.ResponseWriter, r *http.Request) {
+	data := &StudyData{Enabled: studyStore != nil, Dir: strings.Trim(r.FormValue("dir"), "/")}
+	u := currentUser(r)
+	if !data.Enabled || u == nil {
+		data.Login = data.Enabled
+		studyTpl.Execute(w, data)
+		return
+	}
+	data.User = u.Name
+	states, err := studyStore.States(r.Context(), u.Name)
+	if err != nil {
+		log.Println("study.err:", err)
+		w.WriteHeader(http.StatusBadGateway)
+		data.Error = "复习进度读取失败，请稍后再试"
+		studyTpl.Execute(w, data)
+		return
+	}
+
+	now := time.Now()
+	all := visibleCards(r)
+	var cards []flashcard.Card
+	decks := make(map[string][]flashcard.Card)
+	for _, c := range all {
+		if inDir(c.Path, data.Dir) {
+			cards = append(cards, c)
+		}
+		dir := parentDir(c.Path)
+		decks[dir] = append(decks[dir], c)
+	}
+	if data.Dir == "" {
+		for dir, cs := range decks {
+			data.Decks = append(data.Decks, StudyDeck{Dir: dir, Stats: flashcard.Summarize(cs, states, now)})
+		}
+		sort.Slice(data.Decks, func(i, j int) bool { return data.Decks[i].Dir < data.Decks[j].Dir })
+	}
+	data.Stats = flashcard.Summarize(cards, states, now)
+	queue := flashcard.Queue(cards, states, now, studyNewCards)
+	data.Remaining = len(queue)
+	if len(queue) > 0 {
+		data.Card = studyCard(queue[0])
+	}
+	studyTpl.Execute(w, data)
+}
+
+func inDir(p, dir string) bool {
+	return dir == "" || strings.HasPrefix(p, dir+"/")
+}
+
+// studyCard 答案在 Markdown 笔记中按 Markdown 渲染，代码注释中的答案原样显示
+func studyCard(c flashcard.Card) *StudyCard {
+	sc := &StudyCard{Card: c, URL: web.Server.Note(c.Path)}
+	if !strings.HasSuffix(c.Path, ".md") {
+		sc.URL += "#L" + strconv.Itoa(c.Line)
+		sc.AnswerHTML = template.HTML("<pre>" + template.HTMLEscapeString(c.Answer) + "</pre>")
+		return sc
+	}
+	sc.URL += "#" + url.PathEscape(markdown.Slug(c.Question))
+	doc, err := markdown.Render([]byte(c.Answer))
+	if err != nil {
+		sc.AnswerHTML = template.HTML("<pre>" + template.HTMLEscapeString(c.Answer) + "</pre>")
+		return sc
+	}
+	sc.AnswerHTML = template.HTML(doc.HTML)
+	return sc
+}
+
+// studyReview POST /study/review，记录一次复习结果后回到复习页
+func studyReview(w http.ResponseWriter, r *http.Request) {
+	if studyStore == nil {
+		http.NotFound(w, r)
+		return
+	}
+	u := currentUser(r)
+	if u == nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	grade, err := strconv.Atoi(r.FormValue("grade"))
+	if err != nil || grade < 0 || grade > 5 {
+		http.Error(w, "grade must be 0-5", http.StatusBadRequest)
+		return
+	}
+	id := r.FormValue("card")
+	var found bool
+	for _, c := range visibleCards(r) {
+		if c.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		http.Error(w, "card not found", http.StatusNotFound)
+		return
+	}
+
+	states, err := studyStore.States(r.Context(), u.Name)
+	if err != nil {
+		log.Println("study.err:", err)
+		http.Error(w, "复习进度读取失败，请稍后再试", http.StatusBadGateway)
+		return
+	}
+	s, ok := states[id]
+	if !ok {
+		s = flashcard.State{CardID: id}
+	}
+	if err := studyStore.Save(r.Context(), u.Name, s.Review(flashcard.Grade(grade), time.Now())); err != nil {
+		log.Println("study.err:", err)
+		http.Error(w, "复习进度保存失败，请稍后再试", http.StatusBadGateway)
+		return
+	}
+	next := "/study"
+	if dir := strings.Trim(r.FormValue("dir"), "/"); dir != "" {
+		next += "?dir=" + url.QueryEscape(dir)
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
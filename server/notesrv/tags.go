@@ -0,0 +1,73 @@
+package notesrv
+
+import (
+	"html/template"
+	"net/http"
+	"node/pkg/notemeta"
+	"node/web"
+	"sync"
+)
+
+var (
+	tagsTpl  *template.Template
+	metaMu   sync.RWMutex
+	noteMeta = make(map[string]notemeta.Meta) // 相对路径 -> 元信息，随索引增量更新
+)
+
+func setMeta(p string, m notemeta.Meta) {
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	noteMeta[p] = m
+}
+
+func getMeta(p string) notemeta.Meta {
+	metaMu.RLock()
+	defer metaMu.RUnlock()
+	return noteMeta[p]
+}
+
+func retainMeta(keep func(p string) bool) {
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	for p := range noteMeta {
+		if !keep(p) {
+			delete(noteMeta, p)
+		}
+	}
+}
+
+// visibleMeta 当前用户可见笔记的元信息，调用方持有 metaMu 读锁
+func visibleMeta(r *http.Request) map[string]notemeta.Meta {
+	if !authn.ACL.Restricted(currentUser(r)) {
+		return noteMeta
+	}
+	keep := visible(r)
+	m := make(map[string]notemeta.Meta, len(noteMeta))
+	for p, meta := range noteMeta {
+		if keep(p) {
+			m[p] = meta
+		}
+	}
+	return m
+}
+
+// tagsPage 所有标签，按笔记数量倒序
+func tagsPage(w http.ResponseWriter, r *http.Request) {
+	metaMu.RLock()
+	data := &web.TagsData{Site: web.Server, Tags: web.CountTags(visibleMeta(r))}
+	metaMu.RUnlock()
+	tagsTpl.Execute(w, data)
+}
+
+// tagPage 某个标签下的笔记，按日期倒序
+func tagPage(w http.ResponseWriter, r *http.Request) {
+	data := &web.TagsData{Site: web.Server, Tag: r.PathValue("tag")}
+	metaMu.RLock()
+	data.Notes = web.Tagged(visibleMeta(r), data.Tag)
+	metaMu.RUnlock()
+	if len(data.Notes) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	tagsTpl.Execute(w, data)
+}
@@ -0,0 +1,211 @@
+package notesrv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"node/pkg/notestore"
+	"node/pkg/sse"
+	"node/pkg/watcher"
+	"node/web"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dirNode 缓存单个目录渲染好的 <li> 列表，目录未变化时直接复用
+type dirNode struct {
+	html    string
+	entries []*TreeEntry // 供 /api/v1/tree 使用，创建后不再修改
+	files   []string     // 文件的虚拟路径
+	dirs    []string     // 子目录的虚拟路径
+}
+
+var (
+	treeMu    sync.Mutex
+	treeCache = make(map[string]*dirNode)
+	broker    = sse.NewBroker()
+)
+
+// load 全量重建目录树
+func load() {
+	treeMu.Lock()
+	clear(treeCache)
+	treeMu.Unlock()
+	refresh(nil)
+}
+
+// refresh 只重建发生变化的目录及其祖先目录，其余子树复用缓存
+func refresh(changed []string) {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+
+	for _, dir := range changed {
+		for {
+			delete(treeCache, dir)
+			if dir == "" {
+				break
+			}
+			dir = parentDir(dir)
+		}
+	}
+
+	root := build("")
+	invalidatePages()
+	homeText.Store(template.HTML(root.html))
+	treeRoot.Store(&TreeEntry{Type: "dir", Children: root.entries})
+
+	// 清理已删除目录的缓存以及已删除文件的索引
+	seen := make(map[string]struct{})
+	alive := make(map[string]struct{})
+	var mark func(path string)
+	mark = func(path string) {
+		n, ok := treeCache[path]
+		if !ok {
+			return
+		}
+		alive[path] = struct{}{}
+		for _, f := range n.files {
+			seen[f] = struct{}{}
+		}
+		for _, d := range n.dirs {
+			mark(d)
+		}
+	}
+	mark("")
+	for path := range treeCache {
+		if _, ok := alive[path]; !ok {
+			delete(treeCache, path)
+		}
+	}
+	keep := func(p string) bool {
+		_, ok := seen[p]
+		return ok
+	}
+	trackAdded(seen)
+	retainNotes(keep)
+	retainChunks(keep)
+	retainCards(keep)
+	searchIndex.Retain(keep)
+	retainMeta(keep)
+	updateLinks(seen)
+}
+
+func build(path string) *dirNode {
+	if n, ok := treeCache[path]; ok {
+		return n
+	}
+	n := &dirNode{}
+	treeCache[path] = n
+
+	dirs, err := store.ReadDir(path)
+	if err != nil {
+		fmt.Println("read.err:", err)
+		return n
+	}
+	w := &strings.Builder{}
+	for _, d := range dirs {
+		p := d.Name()
+		if path != "" {
+			p = path + "/" + p
+		}
+		entry := &TreeEntry{Name: d.Name(), Path: p}
+		if info, err := d.Info(); err == nil {
+			entry.ModTime = info.ModTime()
+		}
+		if d.IsDir() {
+			child := build(p)
+			web.WriteDir(w, entry.Path, d.Name(), child.html)
+			entry.Type, entry.Children = "dir", child.entries
+			n.dirs = append(n.dirs, p)
+		} else {
+			indexFile(entry.Path, d)
+			meta := getMeta(entry.Path)
+			web.WriteFile(w, web.Server, entry.Path, d.Name(), meta)
+			entry.Type, entry.Title, entry.Tags = "file", meta.Title, meta.Tags
+			if info, err := d.Info(); err == nil {
+				entry.Size = info.Size()
+			}
+			n.files = append(n.files, entry.Path)
+		}
+		n.entries = append(n.entries, entry)
+	}
+	n.html = w.String()
+	return n
+}
+
+// watch 监听所有公开挂载点的变化，刷新目录树并通知浏览器
+func watch(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, m := range store.Mounts() {
+		if !m.Hidden {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				watchMount(ctx, m)
+			}()
+		}
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return nil
+}
+
+// watchMount 监听单个挂载点，监听失败时退化为每分钟全量刷新；ctx 取消后返回
+func watchMount(ctx context.Context, m *notestore.Mount) {
+	w, err := watcher.New(m.Dir, 300*time.Millisecond)
+	if err != nil {
+		log.Println("watcher.err:", err, "fallback to polling")
+		tick := time.NewTicker(time.Minute)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+				load()
+			}
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		w.Close()
+	}()
+	for dirs := range w.Events() {
+		rel := make([]string, 0, len(dirs))
+		for _, d := range dirs {
+			rel = append(rel, virtualPath(m, d))
+		}
+		refresh(rel)
+
+		b, _ := json.Marshal(rel)
+		broker.Publish("change", string(b))
+	}
+}
+
+// virtualPath 挂载点内的绝对路径转换为虚拟路径，虚拟根目录为 ""
+func virtualPath(m *notestore.Mount, dir string) string {
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		rel = ""
+	}
+	return store.Join(m, filepath.ToSlash(rel))
+}
+
+// parentDir 虚拟路径的上级目录
+func parentDir(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// tree 返回目录树片段，首页收到 change 事件后局部刷新
+func tree(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, visibleTree(r))
+}
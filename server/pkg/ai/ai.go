@@ -2,10 +2,12 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/packages/param"
+	"time"
 )
 
 const (
@@ -38,25 +40,95 @@ func AiFIBACountryV2(ctx context.Context, country string) (string, error) {
 }
 
 func Chat(ctx context.Context, prompt string) (string, error) {
+	return sports.Chat(ctx, "You are a sports data expert.", prompt)
+}
 
-	client := openai.NewClient(
-		option.WithBaseURL(Api),
-		option.WithAPIKey(Token),
-	)
+var sports = NewClient(Config{BaseURL: Api, APIKey: Token, Model: Model, MaxTokens: 500})
 
-	chatCompletion, err := client.Chat.Completions.New(ctx,
+// Config OpenAI 兼容接口，对应 config.toml 的 [ai]
+type Config struct {
+	BaseURL        string        `json:"base_url" toml:"base_url"`
+	APIKey         string        `json:"api_key" toml:"api_key"`
+	Model          string        `json:"model" toml:"model"`
+	EmbeddingModel string        `json:"embedding_model" toml:"embedding_model"` // 为空时不使用向量检索
+	MaxTokens      int           `json:"max_tokens" toml:"max_tokens"`
+	Timeout        time.Duration `json:"timeout" toml:"timeout"`
+	Sources        int           `json:"sources" toml:"sources"` // 问答时检索的段落数
+}
+
+// Enabled 是否配置了模型接口
+func (c *Config) Enabled() bool {
+	return c.BaseURL != "" && c.Model != ""
+}
+
+// Client 按 Config 访问 OpenAI 兼容接口
+type Client struct {
+	cfg    Config
+	client openai.Client
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Minute
+	}
+	return &Client{
+		cfg: cfg,
+		client: openai.NewClient(
+			option.WithBaseURL(cfg.BaseURL),
+			option.WithAPIKey(cfg.APIKey),
+			option.WithRequestTimeout(cfg.Timeout),
+		),
+	}
+}
+
+// Chat 单轮对话，system 为系统提示词
+func (c *Client) Chat(ctx context.Context, system, prompt string) (string, error) {
+	resp, err := c.client.Chat.Completions.New(ctx,
 		openai.ChatCompletionNewParams{
 			Messages: []openai.ChatCompletionMessageParamUnion{
-				openai.SystemMessage("You are a sports data expert."),
+				openai.SystemMessage(system),
 				openai.UserMessage(prompt),
 			},
-			Model:     Model,
-			MaxTokens: param.NewOpt(int64(500)),
+			Model:     c.cfg.Model,
+			MaxTokens: param.NewOpt(int64(c.cfg.MaxTokens)),
 		})
 	if err != nil {
 		return "", err
 	}
-	return chatCompletion.Choices[0].Message.Content, err
+	if len(resp.Choices) == 0 {
+		return "", errors.New("chat completion returned no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// CanEmbed 是否配置了向量模型
+func (c *Client) CanEmbed() bool {
+	return c.cfg.EmbeddingModel != ""
+}
+
+// Embed 批量计算文本向量，结果与 texts 一一对应
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	resp, err := c.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Model: c.cfg.EmbeddingModel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding returned %d vectors for %d inputs", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float64, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || int(d.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
 }
 
 func CountryList(ctx context.Context) (string, error) {
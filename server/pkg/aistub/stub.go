@@ -0,0 +1,160 @@
+// Package aistub 本地开发和测试用的 OpenAI 兼容模型替身：/chat/completions 从提示词中摘取
+// 第一个 [n] 片段的首句作为回答，/embeddings 按词的哈希生成向量，结果可预期，不需要网络
+package aistub
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"node/pkg/search"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Dims 向量维度
+const Dims = 256
+
+// Server 处理 chat/completions 和 embeddings，路径可以带任意前缀（如 /v1）
+type Server struct {
+	Model string
+}
+
+func New() *Server {
+	return &Server{Model: "stub"}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method != http.MethodPost:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		s.chat(w, r)
+	case strings.HasSuffix(r.URL.Path, "/embeddings"):
+		s.embeddings(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model    string    `json:"model"`
+		Messages []message `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var prompt string
+	for _, m := range req.Messages {
+		if m.Role == "user" {
+			prompt = m.Content
+		}
+	}
+	writeJSON(w, map[string]any{
+		"id":      "stub",
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   s.model(req.Model),
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       message{Role: "assistant", Content: Answer(prompt)},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]int{"prompt_tokens": 0, "completion_tokens": 0, "total_tokens": 0},
+	})
+}
+
+var sourceHeader = regexp.MustCompile(`(?m)^\[(\d+)\] .*\n`)
+
+// Answer 替身的回答：第一个编号片段正文的首句加上引用标记，没有片段时原样复述问题
+func Answer(prompt string) string {
+	loc := sourceHeader.FindStringSubmatchIndex(prompt)
+	if loc == nil {
+		return "（本地模型替身）" + strings.TrimSpace(prompt)
+	}
+	n := prompt[loc[2]:loc[3]]
+	body := prompt[loc[1]:]
+	if next := sourceHeader.FindStringIndex(body); next != nil {
+		body = body[:next[0]]
+	}
+	body, _, _ = strings.Cut(body, "\n问题：")
+	var sentence string
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			sentence, _, _ = strings.Cut(line, "。")
+			break
+		}
+	}
+	return "（本地模型替身）" + sentence + " [" + n + "]"
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var texts []string
+	if err := json.Unmarshal(req.Input, &texts); err != nil {
+		var text string
+		if err := json.Unmarshal(req.Input, &text); err != nil {
+			http.Error(w, "input must be a string or an array of strings", http.StatusBadRequest)
+			return
+		}
+		texts = []string{text}
+	}
+	data := make([]map[string]any, len(texts))
+	for i, t := range texts {
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": Embed(t)}
+	}
+	writeJSON(w, map[string]any{
+		"object": "list",
+		"data":   data,
+		"model":  s.model(req.Model),
+		"usage":  map[string]int{"prompt_tokens": 0, "total_tokens": 0},
+	})
+}
+
+// Embed 词袋哈希向量，归一化为单位长度；共享的词越多余弦相似度越高
+func Embed(text string) []float64 {
+	v := make([]float64, Dims)
+	for _, t := range search.TokenizeQuery(text) {
+		h := fnv.New32a()
+		h.Write([]byte(t.Term))
+		v[h.Sum32()%Dims]++
+	}
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range v {
+			v[i] /= norm
+		}
+	}
+	return v
+}
+
+func (s *Server) model(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return s.Model
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	Anyone   = "*"     // Rule.Allow 中表示任何人，包括未登录的访问者
+	LoggedIn = "@user" // Rule.Allow 中表示任意已登录用户
+)
+
+// Rule 目录访问规则，对应 [[auth.rules]]
+type Rule struct {
+	Prefix string   `json:"prefix" toml:"prefix"` // 笔记路径前缀，按路径段匹配，"" 匹配全部笔记
+	Allow  []string `json:"allow" toml:"allow"`   // 用户名、组、* 或 @user；为空时任何人都不能访问
+}
+
+// allows 规则是否允许用户访问
+func (r *Rule) allows(u *User) bool {
+	for _, a := range r.Allow {
+		if a == Anyone || a == LoggedIn && u != nil {
+			return true
+		}
+	}
+	return u.In(r.Allow)
+}
+
+// match 前缀按路径段匹配，Docker 匹配 Docker 和 Docker/a.md，不匹配 Dockerfile
+func (r *Rule) match(p string) bool {
+	return r.Prefix == "" || p == r.Prefix || strings.HasPrefix(p, r.Prefix+"/")
+}
+
+// ACL 按最长前缀匹配规则，没有匹配的规则时公开访问
+type ACL struct {
+	rules []Rule
+}
+
+func NewACL(rules []Rule) *ACL {
+	a := &ACL{rules: make([]Rule, 0, len(rules))}
+	for _, r := range rules {
+		r.Prefix = strings.Trim(r.Prefix, "/")
+		a.rules = append(a.rules, r)
+	}
+	sort.SliceStable(a.rules, func(i, j int) bool {
+		return len(a.rules[i].Prefix) > len(a.rules[j].Prefix)
+	})
+	return a
+}
+
+// Allowed 用户（未登录时为 nil）能否访问笔记或目录 p
+func (a *ACL) Allowed(u *User, p string) bool {
+	p = strings.Trim(p, "/")
+	for i := range a.rules {
+		if a.rules[i].match(p) {
+			return a.rules[i].allows(u)
+		}
+	}
+	return true
+}
+
+// Restricted 是否有规则拒绝该用户，没有时可以直接使用未过滤的目录树等缓存
+func (a *ACL) Restricted(u *User) bool {
+	for i := range a.rules {
+		if !a.rules[i].allows(u) {
+			return true
+		}
+	}
+	return false
+}
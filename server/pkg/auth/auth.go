@@ -0,0 +1,120 @@
+// Package auth 笔记服务的登录认证（本地用户、HTTP Basic、会话 cookie、OIDC）和按目录前缀的访问控制
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// User 已登录的用户
+type User struct {
+	Name   string   `json:"n"`
+	Groups []string `json:"g,omitempty"`
+}
+
+// In 用户名或任意一个组出现在 names 中
+func (u *User) In(names []string) bool {
+	if u == nil {
+		return false
+	}
+	for _, n := range names {
+		if n == u.Name || slices.Contains(u.Groups, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// Password 用户名密码认证，HTTP Basic 和登录表单都通过它校验
+type Password interface {
+	Verify(name, password string) (*User, bool)
+}
+
+// Config 对应 config.toml 的 [auth]
+type Config struct {
+	SessionSecret string        `json:"session_secret" toml:"session_secret"` // 会话签名密钥，为空时每次启动随机生成
+	SessionTTL    time.Duration `json:"session_ttl" toml:"session_ttl"`
+	Editors       []string      `json:"editors" toml:"editors"` // 可以编辑笔记的用户名或组，默认 editor 组
+	Users         []UserConfig  `json:"users" toml:"users"`
+	OIDC          OIDCConfig    `json:"oidc" toml:"oidc"`
+	Rules         []Rule        `json:"rules" toml:"rules"`
+}
+
+// Auth 组合各种认证方式，请求中的用户依次从会话 cookie、HTTP Basic 中获取
+type Auth struct {
+	Passwords []Password
+	OIDC      *OIDC // 未配置 issuer 时为空
+	Sessions  *Sessions
+	ACL       *ACL
+	Editors   []string
+}
+
+func New(cfg Config) (*Auth, error) {
+	sessions, err := NewSessions(cfg.SessionSecret, cfg.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	a := &Auth{Sessions: sessions, ACL: NewACL(cfg.Rules), Editors: cfg.Editors}
+	if len(a.Editors) == 0 {
+		a.Editors = []string{"editor"}
+	}
+	if len(cfg.Users) > 0 {
+		local, err := NewLocal(cfg.Users)
+		if err != nil {
+			return nil, err
+		}
+		a.Passwords = append(a.Passwords, local)
+	}
+	if cfg.OIDC.Issuer != "" {
+		a.OIDC = NewOIDC(cfg.OIDC, sessions)
+	}
+	return a, nil
+}
+
+// Enabled 是否有任何登录方式
+func (a *Auth) Enabled() bool {
+	return len(a.Passwords) > 0 || a.OIDC != nil
+}
+
+// Verify 依次尝试各个密码认证方式
+func (a *Auth) Verify(name, password string) (*User, bool) {
+	for _, p := range a.Passwords {
+		if u, ok := p.Verify(name, password); ok {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// CanEdit 用户是否可以编辑笔记
+func (a *Auth) CanEdit(u *User) bool {
+	return u.In(a.Editors)
+}
+
+// Middleware 把请求中的用户放入 context，未登录或认证失败时为空，是否拒绝由后续的处理函数决定
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := a.Sessions.User(r)
+		if name, password, ok := r.BasicAuth(); ok && u == nil {
+			u, _ = a.Verify(name, password)
+		}
+		if u != nil {
+			r = r.WithContext(WithUser(r.Context(), u))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type userKey struct{}
+
+func WithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFrom 未登录时返回 nil
+func UserFrom(ctx context.Context) *User {
+	u, _ := ctx.Value(userKey{}).(*User)
+	return u
+}
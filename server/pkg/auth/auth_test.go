@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"node/pkg/oidcstub"
+	"testing"
+)
+
+func TestLocalAndSession(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(Config{Users: []UserConfig{{Name: "alice", PasswordHash: hash, Groups: []string{"editor"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.Verify("alice", "wrong"); ok {
+		t.Error("wrong password accepted")
+	}
+	if _, ok := a.Verify("bob", "secret"); ok {
+		t.Error("unknown user accepted")
+	}
+
+	var got *User
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = UserFrom(r.Context())
+	}))
+	serve := func(r *http.Request) *User {
+		got = nil
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		return got
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("alice", "secret")
+	if u := serve(r); u == nil || u.Name != "alice" || !a.CanEdit(u) {
+		t.Fatalf("basic auth: %+v", u)
+	}
+
+	w := httptest.NewRecorder()
+	a.Sessions.Issue(w, r, &User{Name: "alice", Groups: []string{"editor"}})
+	cookie := w.Result().Cookies()[0]
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	if u := serve(r); u == nil || u.Name != "alice" {
+		t.Fatalf("session: %+v", u)
+	}
+
+	cookie.Value = cookie.Value[:len(cookie.Value)-2] + "xx"
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	if u := serve(r); u != nil {
+		t.Errorf("tampered session accepted: %+v", u)
+	}
+}
+
+func TestACL(t *testing.T) {
+	acl := NewACL([]Rule{
+		{Prefix: "Docker/", Allow: []string{"ops"}},
+		{Prefix: "Docker/public", Allow: []string{Anyone}},
+		{Prefix: "Private", Allow: []string{LoggedIn}},
+		{Prefix: "Secret"},
+	})
+	ops := &User{Name: "bob", Groups: []string{"ops"}}
+	dev := &User{Name: "carol"}
+	cases := []struct {
+		u    *User
+		p    string
+		want bool
+	}{
+		{nil, "Golang/readme.md", true},
+		{nil, "Docker", false},
+		{nil, "Docker/docker-compose-mysql.yaml", false},
+		{nil, "Dockerfile", true},
+		{ops, "Docker/docker-compose-mysql.yaml", true},
+		{nil, "Docker/public/a.md", true},
+		{nil, "Private/a.md", false},
+		{dev, "Private/a.md", true},
+		{dev, "Docker/a.md", false},
+		{ops, "Secret/a.md", false},
+	}
+	for _, c := range cases {
+		if got := acl.Allowed(c.u, c.p); got != c.want {
+			t.Errorf("Allowed(%v, %q) = %v", c.u, c.p, got)
+		}
+	}
+	if !acl.Restricted(nil) || NewACL(nil).Restricted(nil) {
+		t.Error("Restricted")
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	stub := oidcstub.New("", "notes", "s3cret", oidcstub.User{Name: "dave", Groups: []string{"editor"}})
+	idp := httptest.NewServer(stub)
+	defer idp.Close()
+	stub.Issuer = idp.URL
+
+	var a *Auth
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if err := a.OIDC.Begin(w, r, "/after"); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/auth/callback", func(w http.ResponseWriter, r *http.Request) {
+		u, next, err := a.OIDC.Callback(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		a.Sessions.Issue(w, r, u)
+		http.Redirect(w, r, next, http.StatusFound)
+	})
+	mux.HandleFunc("/after", func(w http.ResponseWriter, r *http.Request) {
+		if u := UserFrom(r.Context()); u == nil || u.Name != "dave" || !a.CanEdit(u) {
+			t.Errorf("user after login: %+v", u)
+		}
+	})
+	app := httptest.NewServer(nil)
+	defer app.Close()
+
+	var err error
+	a, err = New(Config{OIDC: OIDCConfig{Issuer: idp.URL, ClientID: "notes", ClientSecret: "s3cret", RedirectURL: app.URL + "/auth/callback"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Config.Handler = a.Middleware(mux)
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar, CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		// 替身的登录页需要选择用户，这里直接补上 user 参数
+		if req.URL.Path == "/authorize" {
+			q := req.URL.Query()
+			q.Set("user", "dave")
+			req.URL.RawQuery = q.Encode()
+		}
+		return nil
+	}}
+	resp, err := client.Get(app.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/after" {
+		t.Fatalf("login ended at %s: %s", resp.Request.URL, resp.Status)
+	}
+
+	// 回调不能重放
+	resp, err = client.Get(app.URL + "/auth/callback?code=x&state=y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("replayed callback: %s", resp.Status)
+	}
+}
+
+func TestOIDCStateIsNotASession(t *testing.T) {
+	stub := oidcstub.New("", "notes", "s3cret")
+	idp := httptest.NewServer(stub)
+	defer idp.Close()
+	stub.Issuer = idp.URL
+
+	a, err := New(Config{OIDC: OIDCConfig{Issuer: idp.URL, ClientID: "notes", ClientSecret: "s3cret", RedirectURL: "http://app/auth/callback"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	if err := a.OIDC.Begin(rec, httptest.NewRequest("GET", "/login", nil), "/"); err != nil {
+		t.Fatal(err)
+	}
+	var state string
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oidcCookie {
+			state = c.Value
+		}
+	}
+	if state == "" {
+		t.Fatal("no state cookie")
+	}
+
+	// 把 state cookie 的值放进会话 cookie 不能得到登录用户
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: state})
+	if u := a.Sessions.User(r); u != nil {
+		t.Errorf("user from swapped oidc cookie: %+v", u)
+	}
+}
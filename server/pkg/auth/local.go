@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserConfig 本地用户，对应 [[auth.users]]
+type UserConfig struct {
+	Name         string   `json:"name" toml:"name"`
+	PasswordHash string   `json:"password_hash" toml:"password_hash"` // bcrypt，可用 hash-password 命令生成
+	Groups       []string `json:"groups" toml:"groups"`
+}
+
+// Local 配置文件中的本地用户
+type Local struct {
+	users map[string]UserConfig
+	dummy []byte
+}
+
+func NewLocal(users []UserConfig) (*Local, error) {
+	l := &Local{users: make(map[string]UserConfig, len(users))}
+	for _, u := range users {
+		if err := l.Add(u); err != nil {
+			return nil, err
+		}
+	}
+	// 用户不存在时同样做一次 bcrypt 比较，避免通过响应时间判断用户名是否存在
+	l.dummy, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	return l, nil
+}
+
+func (l *Local) Add(u UserConfig) error {
+	if u.Name == "" {
+		return fmt.Errorf("auth: local user without name")
+	}
+	if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+		return fmt.Errorf("auth: user %q: invalid bcrypt hash: %w", u.Name, err)
+	}
+	l.users[u.Name] = u
+	return nil
+}
+
+func (l *Local) Verify(name, password string) (*User, bool) {
+	u, ok := l.users[name]
+	if !ok {
+		bcrypt.CompareHashAndPassword(l.dummy, []byte(password))
+		return nil, false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return nil, false
+	}
+	return &User{Name: u.Name, Groups: u.Groups}, true
+}
+
+// HashPassword 生成 bcrypt 哈希
+func HashPassword(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(b), err
+}
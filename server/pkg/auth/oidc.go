@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const oidcCookie = "note_oidc"
+
+// OIDCConfig 对应 [auth.oidc]，只支持授权码流程
+type OIDCConfig struct {
+	Issuer       string   `json:"issuer" toml:"issuer"`
+	ClientID     string   `json:"client_id" toml:"client_id"`
+	ClientSecret string   `json:"client_secret" toml:"client_secret"`
+	RedirectURL  string   `json:"redirect_url" toml:"redirect_url"` // 例如 http://localhost:1024/auth/callback
+	Scopes       []string `json:"scopes" toml:"scopes"`             // 默认 openid profile email
+	GroupsClaim  string   `json:"groups_claim" toml:"groups_claim"` // ID Token 中组列表的字段，默认 groups
+}
+
+// OIDC 授权码流程的客户端。ID Token 直接从 token 接口获取，按 OIDC Core 3.1.3.7
+// 依赖与提供方之间的 TLS 连接保证来源，不校验签名，只校验 iss、aud、exp 和 nonce
+type OIDC struct {
+	cfg      OIDCConfig
+	sessions *Sessions
+	client   *http.Client
+
+	mu   sync.Mutex
+	meta *discovery
+}
+
+// discovery /.well-known/openid-configuration 中用到的字段
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// oidcState 登录跳转前保存在 cookie 中，回调时校验
+type oidcState struct {
+	State   string `json:"s"`
+	Nonce   string `json:"n"`
+	Next    string `json:"r"`
+	Expires int64  `json:"e"`
+}
+
+func NewOIDC(cfg OIDCConfig, sessions *Sessions) *OIDC {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &OIDC{cfg: cfg, sessions: sessions, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// discover 第一次登录时读取提供方配置，失败时下次重试，提供方可以晚于笔记服务启动
+func (o *OIDC) discover() (*discovery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.meta != nil {
+		return o.meta, nil
+	}
+	resp, err := o.client.Get(o.cfg.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: %s", resp.Status)
+	}
+	meta := &discovery{}
+	if err := json.NewDecoder(resp.Body).Decode(meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != o.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", meta.Issuer)
+	}
+	o.meta = meta
+	return meta, nil
+}
+
+// Begin 跳转到提供方的登录页，登录完成后回到 next
+func (o *OIDC) Begin(w http.ResponseWriter, r *http.Request, next string) error {
+	meta, err := o.discover()
+	if err != nil {
+		return err
+	}
+	st := &oidcState{State: randomString(), Nonce: randomString(), Next: next, Expires: time.Now().Add(10 * time.Minute).Unix()}
+	o.sessions.setCookie(w, r, oidcCookie, o.sessions.seal(purposeOIDCState, st), time.Unix(st.Expires, 0))
+
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {o.cfg.ClientID},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"scope":         {strings.Join(o.cfg.Scopes, " ")},
+		"state":         {st.State},
+		"nonce":         {st.Nonce},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, meta.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+	return nil
+}
+
+// Callback 处理提供方的回调，返回登录的用户和登录前的页面
+func (o *OIDC) Callback(w http.ResponseWriter, r *http.Request) (*User, string, error) {
+	c, err := r.Cookie(oidcCookie)
+	if err != nil {
+		return nil, "", errors.New("oidc: login session not found")
+	}
+	o.sessions.setCookie(w, r, oidcCookie, "", time.Unix(0, 0))
+	var st oidcState
+	if !o.sessions.open(purposeOIDCState, c.Value, &st) || time.Now().Unix() > st.Expires ||
+		subtle.ConstantTimeCompare([]byte(st.State), []byte(r.FormValue("state"))) != 1 {
+		return nil, "", errors.New("oidc: invalid state")
+	}
+	if e := r.FormValue("error"); e != "" {
+		return nil, "", fmt.Errorf("oidc: %s %s", e, r.FormValue("error_description"))
+	}
+
+	claims, err := o.exchange(r.FormValue("code"))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := o.verify(claims, st.Nonce); err != nil {
+		return nil, "", err
+	}
+	u := &User{}
+	for _, k := range []string{"preferred_username", "email", "sub"} {
+		if s, _ := claims[k].(string); s != "" {
+			u.Name = s
+			break
+		}
+	}
+	if groups, ok := claims[o.cfg.GroupsClaim].([]any); ok {
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				u.Groups = append(u.Groups, s)
+			}
+		}
+	}
+	return u, st.Next, nil
+}
+
+// exchange 用授权码换取 ID Token 并解出其中的字段
+func (o *OIDC) exchange(code string) (map[string]any, error) {
+	meta, err := o.discover()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {o.cfg.RedirectURL},
+	}
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token: %s", resp.Status)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("oidc token: %w", err)
+	}
+
+	parts := strings.Split(token.IDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("oidc: malformed id_token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("oidc: malformed id_token: %w", err)
+	}
+	claims := make(map[string]any)
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, fmt.Errorf("oidc: malformed id_token: %w", err)
+	}
+	return claims, nil
+}
+
+func (o *OIDC) verify(claims map[string]any, nonce string) error {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != o.cfg.Issuer {
+		return fmt.Errorf("oidc: unexpected issuer %q", iss)
+	}
+	aud := false
+	switch v := claims["aud"].(type) {
+	case string:
+		aud = v == o.cfg.ClientID
+	case []any:
+		for _, a := range v {
+			aud = aud || a == o.cfg.ClientID
+		}
+	}
+	if !aud {
+		return errors.New("oidc: id_token is not issued for this client")
+	}
+	if exp, _ := claims["exp"].(float64); time.Now().Unix() > int64(exp) {
+		return errors.New("oidc: id_token expired")
+	}
+	if n, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(n), []byte(nonce)) != 1 {
+		return errors.New("oidc: nonce mismatch")
+	}
+	return nil
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const sessionCookie = "note_session"
+
+// 签名时混入的用途，防止一种 cookie 的值被当作另一种使用（如把 OIDC state 当作会话）
+const (
+	purposeSession   = "session"
+	purposeOIDCState = "oidc-state"
+)
+
+// Sessions 无状态的会话：用户信息和过期时间签名后放在 cookie 中
+type Sessions struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSessions secret 为空时随机生成，服务重启后已有会话失效；ttl 为 0 时为 24 小时
+func NewSessions(secret string, ttl time.Duration) (*Sessions, error) {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Sessions{key: key, ttl: ttl}, nil
+}
+
+type session struct {
+	User
+	Expires int64 `json:"e"`
+}
+
+// Issue 登录成功后写入会话 cookie
+func (s *Sessions) Issue(w http.ResponseWriter, r *http.Request, u *User) {
+	expires := time.Now().Add(s.ttl)
+	s.setCookie(w, r, sessionCookie, s.seal(purposeSession, &session{User: *u, Expires: expires.Unix()}), expires)
+}
+
+// User 从会话 cookie 中取出用户，cookie 不存在、被篡改或已过期时返回 nil
+func (s *Sessions) User(r *http.Request) *User {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	var sess session
+	if !s.open(purposeSession, c.Value, &sess) || time.Now().Unix() > sess.Expires {
+		return nil
+	}
+	return &sess.User
+}
+
+// Clear 退出登录
+func (s *Sessions) Clear(w http.ResponseWriter, r *http.Request) {
+	s.setCookie(w, r, sessionCookie, "", time.Unix(0, 0))
+}
+
+func (s *Sessions) setCookie(w http.ResponseWriter, r *http.Request, name, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// seal 序列化并签名：base64(json).base64(hmac(purpose, json))
+func (s *Sessions) seal(purpose string, v any) string {
+	b, _ := json.Marshal(v)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(purpose, payload))
+}
+
+func (s *Sessions) open(purpose, token string, v any) bool {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(purpose, payload)) {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	return err == nil && json.Unmarshal(b, v) == nil
+}
+
+func (s *Sessions) mac(purpose, payload string) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(purpose))
+	m.Write([]byte{0})
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
@@ -0,0 +1,242 @@
+// Package bookmarks 首页书签：分类和链接保存在 TOML 文件中，支持在线增删和定时检查链接能否访问
+package bookmarks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid bookmark")
+	ErrExists   = errors.New("bookmark already exists")
+	ErrNotFound = errors.New("bookmark not found")
+)
+
+// Link 一个书签，Icon 为图标地址，可以为空
+type Link struct {
+	Name string `json:"name" toml:"name"`
+	URL  string `json:"url" toml:"url"`
+	Icon string `json:"icon,omitempty" toml:"icon,omitempty"`
+}
+
+// Category 一组书签，按文件中的顺序显示
+type Category struct {
+	Name  string `json:"name" toml:"name"`
+	Links []Link `json:"links" toml:"links"`
+}
+
+// file 书签文件的格式：
+//
+//	[[categories]]
+//	name = '语言'
+//	[[categories.links]]
+//	name = 'Golang'
+//	url = 'https://go.dev/'
+//	icon = 'https://go.dev/images/favicon-gopher.png'
+type file struct {
+	Categories []Category `toml:"categories"`
+}
+
+// Load 读取书签文件，文件不存在时返回空列表
+func Load(path string) ([]Category, error) {
+	var f file
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("bookmarks %s: %w", path, err)
+	}
+	for _, c := range f.Categories {
+		for _, l := range c.Links {
+			if err := l.validate(); err != nil {
+				return nil, fmt.Errorf("bookmarks %s: %s: %w", path, c.Name, err)
+			}
+		}
+	}
+	return f.Categories, nil
+}
+
+// Save 先写临时文件再改名，写入中途失败不会损坏原文件
+func Save(path string, categories []Category) error {
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(file{Categories: categories}); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".bookmarks-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Link) validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an http(s) url", ErrInvalid, l.URL)
+	}
+	return nil
+}
+
+// Store 内存中的书签和最近一次检查结果，修改后立即写回文件。
+// 在线修改会按 TOML 重新生成整个文件，手写的注释不会保留
+type Store struct {
+	path string
+
+	mu         sync.RWMutex
+	categories []Category
+	status     map[string]Status // 按 URL
+}
+
+// Open 读取书签文件，文件不存在时从空列表开始，第一次修改时创建
+func Open(path string) (*Store, error) {
+	categories, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path, categories: categories, status: make(map[string]Status)}, nil
+}
+
+// Categories 返回书签的副本
+func (s *Store) Categories() []Category {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return clone(s.categories)
+}
+
+// Status 返回最近一次检查结果的副本，没有检查过的链接不在其中
+func (s *Store) Status() map[string]Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m := make(map[string]Status, len(s.status))
+	for k, v := range s.status {
+		m[k] = v
+	}
+	return m
+}
+
+// Add 把链接添加到分类末尾，分类不存在时新建在最后；同一分类中 URL 不能重复
+func (s *Store) Add(category string, l Link) error {
+	category = strings.TrimSpace(category)
+	l.Name, l.URL, l.Icon = strings.TrimSpace(l.Name), strings.TrimSpace(l.URL), strings.TrimSpace(l.Icon)
+	if category == "" {
+		return fmt.Errorf("%w: category is required", ErrInvalid)
+	}
+	if err := l.validate(); err != nil {
+		return err
+	}
+	if l.Icon != "" {
+		if u, err := url.Parse(l.Icon); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w: icon %q is not an http(s) url", ErrInvalid, l.Icon)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := clone(s.categories)
+	i := index(next, category)
+	if i < 0 {
+		next = append(next, Category{Name: category})
+		i = len(next) - 1
+	}
+	for _, old := range next[i].Links {
+		if old.URL == l.URL {
+			return fmt.Errorf("%w: %s in %s", ErrExists, l.URL, category)
+		}
+	}
+	next[i].Links = append(next[i].Links, l)
+	return s.save(next)
+}
+
+// Remove 删除分类中的链接，分类删空后一并删除
+func (s *Store) Remove(category, link string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := clone(s.categories)
+	i := index(next, category)
+	if i < 0 {
+		return fmt.Errorf("%w: category %s", ErrNotFound, category)
+	}
+	links := next[i].Links[:0]
+	for _, l := range next[i].Links {
+		if l.URL != link {
+			links = append(links, l)
+		}
+	}
+	if len(links) == len(next[i].Links) {
+		return fmt.Errorf("%w: %s in %s", ErrNotFound, link, category)
+	}
+	if len(links) == 0 {
+		next = append(next[:i], next[i+1:]...)
+	} else {
+		next[i].Links = links
+	}
+	return s.save(next)
+}
+
+// save 写入文件成功后才替换内存中的书签，调用方持有写锁
+func (s *Store) save(next []Category) error {
+	if err := Save(s.path, next); err != nil {
+		return err
+	}
+	s.categories = next
+	return nil
+}
+
+// urls 全部链接，多个分类中重复的只出现一次
+func (s *Store) urls() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]bool)
+	var urls []string
+	for _, c := range s.categories {
+		for _, l := range c.Links {
+			if !seen[l.URL] {
+				seen[l.URL] = true
+				urls = append(urls, l.URL)
+			}
+		}
+	}
+	return urls
+}
+
+func index(categories []Category, name string) int {
+	for i, c := range categories {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func clone(categories []Category) []Category {
+	if categories == nil {
+		return nil
+	}
+	c := make([]Category, len(categories))
+	for i, cat := range categories {
+		c[i] = Category{Name: cat.Name, Links: append([]Link(nil), cat.Links...)}
+	}
+	return c
+}
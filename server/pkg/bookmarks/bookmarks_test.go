@@ -0,0 +1,130 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.toml")
+	s, err := Open(path)
+	if err != nil || len(s.Categories()) != 0 {
+		t.Fatalf("open missing file: %v %+v", err, s.Categories())
+	}
+
+	if err := s.Add("语言", Link{Name: "Golang", URL: "https://go.dev/", Icon: "https://go.dev/images/favicon-gopher.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("语言", Link{Name: " Node.js ", URL: "https://nodejs.org/"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("工具", Link{Name: "GitHub", URL: "https://github.com/"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		category string
+		link     Link
+		want     error
+	}{
+		{"语言", Link{Name: "Go", URL: "https://go.dev/"}, ErrExists},
+		{"语言", Link{Name: "x", URL: "javascript:alert(1)"}, ErrInvalid},
+		{"语言", Link{Name: "x", URL: "https://x.dev/", Icon: "javascript:alert(1)"}, ErrInvalid},
+		{"语言", Link{URL: "https://x.dev/"}, ErrInvalid},
+		{"", Link{Name: "x", URL: "https://x.dev/"}, ErrInvalid},
+	} {
+		if err := s.Add(c.category, c.link); !errors.Is(err, c.want) {
+			t.Errorf("add %+v: got %v, want %v", c.link, err, c.want)
+		}
+	}
+	if err := s.Remove("工具", "https://leetcode.cn/"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("remove missing: %v", err)
+	}
+	// 分类删空后一并删除
+	if err := s.Remove("工具", "https://github.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改已经写回文件
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "语言" || len(got[0].Links) != 2 ||
+		got[0].Links[0].Icon == "" || got[0].Links[1].Name != "Node.js" {
+		t.Errorf("reloaded: %+v", got)
+	}
+
+	// 返回的是副本
+	s.Categories()[0].Links[0].Name = "changed"
+	if s.Categories()[0].Links[0].Name != "Golang" {
+		t.Error("Categories should return a copy")
+	}
+}
+
+func TestChecker(t *testing.T) {
+	var agents sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents.Store(r.UserAgent(), true)
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s, err := Open(filepath.Join(t.TempDir(), "bookmarks.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/ok", "/no-head", "/missing", "/slow"} {
+		if err := s.Add("test", Link{Name: p, URL: srv.URL + p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := NewChecker(Config{Timeout: 100 * time.Millisecond, UserAgent: "bookmarks-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.CheckAll(context.Background(), s)
+
+	status := s.Status()
+	if st := status[srv.URL+"/ok"]; !st.OK || st.Code != http.StatusOK || st.Checked.IsZero() {
+		t.Errorf("ok: %+v", st)
+	}
+	if st := status[srv.URL+"/no-head"]; !st.OK {
+		t.Errorf("HEAD not allowed should fall back to GET: %+v", st)
+	}
+	if st := status[srv.URL+"/missing"]; st.OK || st.Code != http.StatusNotFound {
+		t.Errorf("missing: %+v", st)
+	}
+	if st := status[srv.URL+"/slow"]; st.OK || st.Error == "" {
+		t.Errorf("timeout: %+v", st)
+	}
+	if _, ok := agents.Load("bookmarks-test"); !ok {
+		t.Error("user agent not sent")
+	}
+
+	// 删除的链接在下一轮检查后不再有结果
+	s.Remove("test", srv.URL+"/missing")
+	c.CheckAll(context.Background(), s)
+	if _, ok := s.Status()[srv.URL+"/missing"]; ok || len(s.Status()) != 3 {
+		t.Errorf("status after remove: %+v", s.Status())
+	}
+
+	if _, err := NewChecker(Config{Proxy: "://bad"}); err == nil {
+		t.Error("invalid proxy should fail")
+	}
+}
@@ -0,0 +1,155 @@
+package bookmarks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Config 书签配置，对应 config.toml 的 [bookmarks]；check_interval 为 0 时不检查链接
+type Config struct {
+	File          string        `json:"file" toml:"file"` // 书签文件，相对路径相对于配置文件所在目录
+	CheckInterval time.Duration `json:"check_interval" toml:"check_interval"`
+	Timeout       time.Duration `json:"timeout" toml:"timeout"` // 单个链接的超时时间
+	Concurrency   int           `json:"concurrency" toml:"concurrency"`
+	UserAgent     string        `json:"user_agent" toml:"user_agent"`
+	Proxy         string        `json:"proxy" toml:"proxy"`                               // 为空时使用 HTTP_PROXY 等环境变量
+	Insecure      bool          `json:"insecure_skip_verify" toml:"insecure_skip_verify"` // 不校验证书，用于自签名的内网站点
+}
+
+// Status 一个链接最近一次的检查结果
+type Status struct {
+	OK      bool          `json:"ok"`
+	Code    int           `json:"code,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+	Checked time.Time     `json:"checked"`
+}
+
+// Checker 按 Config 构造的 HTTP 客户端检查链接
+type Checker struct {
+	client      *http.Client
+	userAgent   string
+	concurrency int
+}
+
+func NewChecker(cfg Config) (*Checker, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "notes-bookmarks/1.0"
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("bookmarks proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if cfg.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &Checker{
+		client:      &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		userAgent:   cfg.UserAgent,
+		concurrency: cfg.Concurrency,
+	}, nil
+}
+
+// Check 先发 HEAD，服务端不支持 HEAD 时改用 GET；跟随重定向后状态码小于 400 即可访问
+func (c *Checker) Check(ctx context.Context, link string) Status {
+	start := time.Now()
+	code, err := c.do(ctx, http.MethodHead, link)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		code, err = c.do(ctx, http.MethodGet, link)
+	}
+	s := Status{Code: code, Latency: time.Since(start), Checked: time.Now()}
+	switch {
+	case err != nil:
+		s.Error = err.Error()
+	case code >= http.StatusBadRequest:
+		s.Error = http.StatusText(code)
+	default:
+		s.OK = true
+	}
+	return s
+}
+
+func (c *Checker) do(ctx context.Context, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读掉少量响应体以便复用连接，大的响应直接关闭
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// CheckAll 并发检查全部链接，完成后整体替换检查结果，已删除链接的结果随之清除
+func (c *Checker) CheckAll(ctx context.Context, s *Store) {
+	urls := s.urls()
+	status := make(map[string]Status, len(urls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+	for _, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			st := c.Check(ctx, u)
+			mu.Lock()
+			status[u] = st
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+	var failed int
+	for u, st := range status {
+		if !st.OK {
+			failed++
+			log.Printf("bookmarks: %s unreachable: %s", u, st.Error)
+		}
+	}
+	log.Printf("bookmarks: checked %d links, %d unreachable", len(status), failed)
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+// Run 启动后立即检查一次，之后每隔 interval 检查，ctx 取消时返回
+func (c *Checker) Run(ctx context.Context, s *Store, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.CheckAll(ctx, s)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
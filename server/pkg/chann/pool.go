@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"node/pkg/metrics"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+var (
+	poolQueueDepth   = metrics.NewGauge("worker_pool_queue_depth", "Tasks waiting in the worker pool queue.", "pool")
+	poolTasks        = metrics.NewCounter("worker_pool_tasks_total", "Tasks finished by the worker pool.", "pool", "result")
+	poolTaskDuration = metrics.NewHistogram("worker_pool_task_duration_seconds", "Task execution time in the worker pool.", metrics.DefBuckets, "pool")
+)
+
 // Task 定义任务接口：包含超时执行+ 成功/失败回调
 type Task interface {
 	Execute(ctx context.Context) (any, error) //支持超时控制
@@ -18,6 +25,7 @@ type Task interface {
 
 // workerPool 协程池结构体
 type WorkerPool struct {
+	Name      string    //指标中的 pool 标签，为空时为 default
 	taskQueue chan Task //任务队列
 	poolSize  int       //最大并发数
 	wg        sync.WaitGroup
@@ -47,7 +55,14 @@ func NewWorkerPool(poolSize, taskQueueSize int, taskTimeout time.Duration) *Work
 	}
 }
 
-// GetStats 获取协程池监控指标
+func (wp *WorkerPool) name() string {
+	if wp.Name == "" {
+		return "default"
+	}
+	return wp.Name
+}
+
+// GetStats 获取协程池监控指标，/metrics 中对应 worker_pool_* 指标
 func (wp *WorkerPool) GetStats() map[string]any {
 	//计算平均执行时间
 	avgExecTime := 0.0
@@ -83,7 +98,8 @@ func (wp *WorkerPool) AddTask(task Task) bool {
 		fmt.Println("协程池已关闭, 拒绝添加任务")
 		return false
 	case wp.taskQueue <- task:
-		atomic.AddUint64(&wp.successTasks, 1)
+		atomic.AddUint64(&wp.totalTasks, 1)
+		poolQueueDepth.With(wp.name()).Set(float64(len(wp.taskQueue)))
 		return true
 	default:
 		fmt.Println("任务队列已满， 添加失败")
@@ -117,6 +133,7 @@ func (wp *WorkerPool) workerLoop(workerID int, defaultTimeout time.Duration) {
 				fmt.Printf("工作协程 %d 任务队列已关闭， 退出\n", workerID)
 				return
 			}
+			poolQueueDepth.With(wp.name()).Set(float64(len(wp.taskQueue)))
 
 			//创建带有超时的上下文，覆盖整个任务流程
 			taskCtx, taskCancel := context.WithTimeout(context.Background(), defaultTimeout)
@@ -155,14 +172,17 @@ func (wp *WorkerPool) workerLoop(workerID int, defaultTimeout time.Duration) {
 			//-------------监控指标统计
 			execDuration := time.Since(startTime)
 			atomic.AddInt64(&wp.totalExecTime, execDuration.Milliseconds())
+			poolTaskDuration.With(wp.name()).Observe(execDuration.Seconds())
 			//-------------监控指标统计结束
 
 			if err != nil {
 				//任务失败
 				atomic.AddUint64(&wp.failureTasks, 1)
+				poolTasks.With(wp.name(), "failure").Inc()
 				task.OnError(err)
 			} else {
 				atomic.AddUint64(&wp.successTasks, 1)
+				poolTasks.With(wp.name(), "success").Inc()
 				task.OnComplete(result)
 			}
 
@@ -1,6 +1,8 @@
 package chann
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -70,3 +72,42 @@ func TestName(t *testing.T) {
 	time.Sleep(5 * time.Second)
 	pool.Stop()
 }
+
+// statTask 立即返回，fail 为 true 时返回错误
+type statTask struct{ fail bool }
+
+func (t statTask) Execute(ctx context.Context) (any, error) {
+	if t.fail {
+		return nil, errors.New("fail")
+	}
+	return "ok", nil
+}
+func (t statTask) OnComplete(result any) {}
+func (t statTask) OnError(err error)     {}
+
+func TestStats(t *testing.T) {
+	pool := NewWorkerPool(2, 10, time.Second)
+	pool.Start(time.Second)
+	defer pool.Stop()
+
+	for _, fail := range []bool{false, true, false, true, false} {
+		if !pool.AddTask(statTask{fail: fail}) {
+			t.Fatal("AddTask failed")
+		}
+	}
+	// 入队的任务计入 total_tasks，执行结果分别计入 success_tasks、failure_tasks
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := pool.GetStats()
+		if stats["success_tasks"].(uint64)+stats["failure_tasks"].(uint64) == 5 {
+			if stats["total_tasks"] != uint64(5) || stats["success_tasks"] != uint64(3) || stats["failure_tasks"] != uint64(2) {
+				t.Errorf("stats: %v", stats)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("tasks not finished: %v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,234 @@
+// Package compress 按 Accept-Encoding 协商 brotli / gzip 压缩响应
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+
+	// MinSize 小于该大小的响应压缩收益很小，不压缩
+	MinSize = 1024
+	// 动态响应每次都要压缩，使用较快的级别；缓存的内容只压缩一次，级别高一些，
+	// brotli 最高的 11 级对大笔记太慢
+	brotliLevel       = 5
+	brotliCachedLevel = 8
+)
+
+var gzipPool = sync.Pool{New: func() any {
+	w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+	return w
+}}
+
+// Negotiate 返回客户端接受的编码，优先 brotli，都不接受时返回空
+func Negotiate(r *http.Request) string {
+	var br, gz bool
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case Brotli:
+			br = true
+		case Gzip, "x-gzip":
+			gz = true
+		}
+	}
+	switch {
+	case br:
+		return Brotli
+	case gz:
+		return Gzip
+	default:
+		return ""
+	}
+}
+
+// Compressible 文本类内容值得压缩；事件流和 NDJSON 需要逐条送达，不压缩
+func Compressible(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch t {
+	case "text/event-stream", "application/x-ndjson":
+		return false
+	case "application/json", "application/javascript", "application/xml", "application/atom+xml",
+		"image/svg+xml", "image/x-icon", "image/vnd.microsoft.icon":
+		return true
+	}
+	return strings.HasPrefix(t, "text/")
+}
+
+// Encode 一次性压缩，用于缓存的页面和静态资源
+func Encode(encoding string, b []byte) []byte {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case Brotli:
+		w = brotli.NewWriterLevel(buf, brotliCachedLevel)
+	case Gzip:
+		w, _ = gzip.NewWriterLevel(buf, gzip.BestCompression)
+	default:
+		return b
+	}
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+// Vary 标记响应随 Accept-Encoding 变化，已经标记过时不重复添加
+func Vary(h http.Header) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// Handler 压缩 next 的响应。已经设置了 Content-Encoding 的响应原样输出
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := Negotiate(r)
+		Vary(w.Header())
+		if enc == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &writer{ResponseWriter: w, encoding: enc}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// writer 缓冲开头 MinSize 字节后决定是否压缩，响应头推迟到决定之后写出
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	code     int  // 处理函数设置的状态码，0 表示还没有写响应头
+	decided  bool // 是否已经写出响应头
+	buf      []byte
+	w        io.WriteCloser // 为空时不压缩
+	flusher  interface{ Flush() error }
+}
+
+func (cw *writer) WriteHeader(code int) {
+	if cw.code != 0 {
+		return
+	}
+	cw.code = code
+	h := cw.Header()
+	if code != http.StatusOK || h.Get("Content-Encoding") != "" || !Compressible(h.Get("Content-Type")) {
+		cw.decide(false)
+		return
+	}
+	if size, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+		cw.decide(size >= MinSize)
+	}
+}
+
+// decide 写出响应头，compress 为 true 时之后的内容经过压缩
+func (cw *writer) decide(compress bool) {
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		switch cw.encoding {
+		case Brotli:
+			bw := brotli.NewWriterLevel(cw.ResponseWriter, brotliLevel)
+			cw.w, cw.flusher = bw, bw
+		case Gzip:
+			gw := gzipPool.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.w, cw.flusher = gw, gw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.code)
+	if len(cw.buf) > 0 {
+		buf := cw.buf
+		cw.buf = nil
+		cw.write(buf)
+	}
+}
+
+func (cw *writer) write(b []byte) (int, error) {
+	if cw.w == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+	return cw.w.Write(b)
+}
+
+func (cw *writer) Write(b []byte) (int, error) {
+	if cw.code == 0 {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		return cw.write(b)
+	}
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= MinSize {
+		cw.decide(true)
+	}
+	return len(b), nil
+}
+
+// Flush 先把压缩器中的数据写出，流式响应才能及时送达
+func (cw *writer) Flush() {
+	cw.FlushError()
+}
+
+func (cw *writer) FlushError() error {
+	if cw.code == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if cw.flusher != nil {
+		if err := cw.flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close 响应结束时不足 MinSize 的内容不压缩
+func (cw *writer) Close() error {
+	if cw.code != 0 && !cw.decided {
+		cw.decide(false)
+	}
+	if cw.w == nil {
+		return nil
+	}
+	err := cw.w.Close()
+	if gw, ok := cw.w.(*gzip.Writer); ok {
+		gzipPool.Put(gw)
+	}
+	cw.w = nil
+	return err
+}
+
+// Unwrap 供 http.ResponseController 设置超时等
+func (cw *writer) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package compress
+
+import (
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"gzip, deflate":            Gzip,
+		"gzip, deflate, br, zstd":  Brotli,
+		"br;q=0, gzip;q=0.5":       Gzip,
+		"identity":                 "",
+		"GZIP;q=1.0, x-gzip;q=0.1": Gzip,
+	}
+	for header, want := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", header)
+		if got := Negotiate(r); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	page := strings.Repeat("<p>hello</p>\n", 200)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			io.WriteString(w, "<p>hi</p>")
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, page)
+		default:
+			io.WriteString(w, page)
+		}
+	}))
+	get := func(path, enc string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept-Encoding", enc)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	for enc, reader := range map[string]func(io.Reader) io.Reader{
+		Gzip: func(r io.Reader) io.Reader {
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return zr
+		},
+		Brotli: func(r io.Reader) io.Reader { return brotli.NewReader(r) },
+	} {
+		resp := get("/", enc)
+		if resp.Header.Get("Content-Encoding") != enc || resp.Header.Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("%s: headers %v", enc, resp.Header)
+		}
+		b, err := io.ReadAll(reader(resp.Body))
+		if err != nil || string(b) != page {
+			t.Errorf("%s: body mismatch: %v", enc, err)
+		}
+	}
+	for _, path := range []string{"/small", "/stream"} {
+		if resp := get(path, "br, gzip"); resp.Header.Get("Content-Encoding") != "" {
+			t.Errorf("%s should not be compressed", path)
+		}
+	}
+}
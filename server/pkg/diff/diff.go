@@ -0,0 +1,152 @@
+package diff
+
+import "strings"
+
+// Op 行的变化类型
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Delete Op = "delete"
+	Insert Op = "insert"
+	Change Op = "change"
+)
+
+// Line 一侧的行，Num 为 0 表示该侧没有对应行
+type Line struct {
+	Num  int
+	Text string
+}
+
+// Row 并排对比的一行
+type Row struct {
+	Op    Op
+	Left  Line
+	Right Line
+}
+
+// maxCells LCS 矩阵上限，超过后退化为整体替换，避免超大文件占用过多内存
+const maxCells = 16 << 20
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// SideBySide 按行对比 a、b，相邻的删除和新增配对为 Change 行
+func SideBySide(a, b string) []Row {
+	x, y := splitLines(a), splitLines(b)
+	ops := lcs(x, y)
+
+	var (
+		rows       []Row
+		dels, adds []Line
+		i, j       int
+	)
+	flush := func() {
+		n := max(len(dels), len(adds))
+		for k := 0; k < n; k++ {
+			row := Row{Op: Change}
+			if k < len(dels) {
+				row.Left = dels[k]
+			} else {
+				row.Op = Insert
+			}
+			if k < len(adds) {
+				row.Right = adds[k]
+			} else {
+				row.Op = Delete
+			}
+			rows = append(rows, row)
+		}
+		dels, adds = dels[:0], adds[:0]
+	}
+	for _, op := range ops {
+		switch op {
+		case Equal:
+			flush()
+			rows = append(rows, Row{Op: Equal, Left: Line{i + 1, x[i]}, Right: Line{j + 1, y[j]}})
+			i++
+			j++
+		case Delete:
+			dels = append(dels, Line{i + 1, x[i]})
+			i++
+		case Insert:
+			adds = append(adds, Line{j + 1, y[j]})
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+// Stat 统计 a 到 b 新增和删除的行数，修改的行同时计入两者
+func Stat(a, b string) (added, removed int) {
+	for _, op := range lcs(splitLines(a), splitLines(b)) {
+		switch op {
+		case Insert:
+			added++
+		case Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// lcs 经典动态规划求最长公共子序列，返回编辑脚本
+func lcs(x, y []string) []Op {
+	n, m := len(x), len(y)
+	if (n+1)*(m+1) > maxCells {
+		ops := make([]Op, 0, n+m)
+		for range x {
+			ops = append(ops, Delete)
+		}
+		for range y {
+			ops = append(ops, Insert)
+		}
+		return ops
+	}
+
+	// dp[i][j] 为 x[i:] 与 y[j:] 的 LCS 长度
+	dp := make([][]int32, n+1)
+	for i := range dp {
+		dp[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case x[i] == y[j]:
+			ops = append(ops, Equal)
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			ops = append(ops, Delete)
+			i++
+		default:
+			ops = append(ops, Insert)
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Delete)
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Insert)
+	}
+	return ops
+}
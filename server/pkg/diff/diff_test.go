@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSideBySide(t *testing.T) {
+	a := "package main\nfunc a() {}\nfunc b() {}\n// end\n"
+	b := "package main\nfunc a2() {}\nfunc b() {}\nfunc c() {}\n// end\n"
+
+	var got []string
+	for _, r := range SideBySide(a, b) {
+		got = append(got, fmt.Sprintf("%s %d:%s|%d:%s", r.Op, r.Left.Num, r.Left.Text, r.Right.Num, r.Right.Text))
+	}
+	want := []string{
+		"equal 1:package main|1:package main",
+		"change 2:func a() {}|2:func a2() {}",
+		"equal 3:func b() {}|3:func b() {}",
+		"insert 0:|4:func c() {}",
+		"equal 4:// end|5:// end",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s", strings.Join(got, "\n"))
+	}
+
+	rows := SideBySide("x\ny\n", "")
+	if len(rows) != 2 || rows[0].Op != Delete || rows[1].Right.Num != 0 {
+		t.Fatalf("delete all: %+v", rows)
+	}
+}
+
+func TestStat(t *testing.T) {
+	added, removed := Stat("a\nb\nc\n", "a\nB\nc\nd\n")
+	if added != 2 || removed != 1 {
+		t.Fatalf("added=%d removed=%d", added, removed)
+	}
+	if added, removed = Stat("", "x\n"); added != 1 || removed != 0 {
+		t.Fatalf("added=%d removed=%d", added, removed)
+	}
+}
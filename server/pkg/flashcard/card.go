@@ -0,0 +1,178 @@
+// Package flashcard 从笔记中抽取问答卡片，按 SM-2 间隔重复算法安排复习
+package flashcard
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Card 一张问答卡片
+type Card struct {
+	ID       string `json:"id"` // 由笔记路径和问题生成，修改答案不影响复习进度
+	Path     string `json:"path"`
+	Line     int    `json:"line"` // 问题所在行，从 1 开始
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// CardID 卡片 ID
+func CardID(p, question string) string {
+	sum := sha1.Sum([]byte(p + "\x00" + question))
+	return hex.EncodeToString(sum[:8])
+}
+
+var (
+	questionMarker = regexp.MustCompile(`^(?:Q|问)\s*[:：]\s*`)
+	answerMarker   = regexp.MustCompile(`^(?:A|答)\s*[:：]\s*`)
+)
+
+// Extract 抽取笔记中的卡片：
+//   - 以 Q: / 问： 开头的行是问题，之后 A: / 答： 开头的行开始答案，到下一个问题为止；
+//     代码文件只识别注释中的标记，注释结束时卡片结束
+//   - Markdown 中以问号结尾的标题是问题，标题下的内容（到同级或更高级标题为止）是答案
+func Extract(p, content string) []Card {
+	x := &extractor{path: p}
+	if strings.EqualFold(path.Ext(p), ".md") {
+		x.markdown(content)
+	} else {
+		x.code(content)
+	}
+	x.flush()
+	return x.cards
+}
+
+type extractor struct {
+	path  string
+	cards []Card
+
+	cur      *Card
+	level    int // 标题问题的级别，标记问题为 0
+	inAnswer bool
+	question []string
+	answer   []string
+}
+
+func (x *extractor) start(n int, question string, level int) {
+	x.flush()
+	x.cur = &Card{Path: x.path, Line: n}
+	x.level = level
+	x.inAnswer = level > 0
+	x.question = []string{question}
+}
+
+func (x *extractor) flush() {
+	if x.cur == nil {
+		return
+	}
+	c := x.cur
+	x.cur = nil
+	c.Question = strings.TrimSpace(strings.Join(x.question, "\n"))
+	c.Answer = strings.TrimSpace(strings.Join(x.answer, "\n"))
+	x.question, x.answer = nil, nil
+	if c.Question == "" || c.Answer == "" {
+		return
+	}
+	c.ID = CardID(c.Path, c.Question)
+	x.cards = append(x.cards, *c)
+}
+
+// text 处理第 n 行正文
+func (x *extractor) text(n int, s string) {
+	t := strings.TrimSpace(s)
+	if m := questionMarker.FindString(t); m != "" {
+		x.start(n, t[len(m):], 0)
+		return
+	}
+	if x.cur == nil {
+		return
+	}
+	if m := answerMarker.FindString(t); m != "" && !x.inAnswer {
+		x.inAnswer = true
+		x.answer = append(x.answer, t[len(m):])
+		return
+	}
+	if x.inAnswer {
+		x.answer = append(x.answer, s)
+	} else {
+		x.question = append(x.question, t)
+	}
+}
+
+func (x *extractor) markdown(content string) {
+	fence := ""
+	for i, s := range strings.Split(content, "\n") {
+		n := i + 1
+		s = strings.TrimRight(s, "\r")
+		t := strings.TrimSpace(s)
+		if fence != "" {
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+			x.text(n, s)
+			continue
+		}
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fence = t[:3]
+			x.text(n, s)
+			continue
+		}
+		level := strings.IndexFunc(t, func(r rune) bool { return r != '#' })
+		if level < 1 || level > 6 || t[level] != ' ' {
+			x.text(n, s)
+			continue
+		}
+		heading := strings.TrimSpace(t[level:])
+		switch {
+		case strings.HasSuffix(heading, "?") || strings.HasSuffix(heading, "？"):
+			x.start(n, heading, level)
+		case x.cur != nil && x.level > 0 && level > x.level:
+			// 问题标题下的子标题属于答案
+			x.answer = append(x.answer, s)
+		default:
+			x.flush()
+		}
+	}
+}
+
+// code 只处理 // 和 /* */ 注释
+func (x *extractor) code(content string) {
+	inBlock := false
+	for i, s := range strings.Split(content, "\n") {
+		n := i + 1
+		s = strings.TrimRight(s, "\r")
+		t := strings.TrimSpace(s)
+		switch {
+		case inBlock:
+			if before, _, ok := strings.Cut(t, "*/"); ok {
+				inBlock = false
+				if before = strings.TrimPrefix(strings.TrimSpace(before), "*"); before != "" {
+					x.text(n, before)
+				}
+				x.flush()
+				continue
+			}
+			// 块注释中常见的行首 *
+			if strings.HasPrefix(t, "*") {
+				s = strings.TrimPrefix(t, "*")
+			}
+			x.text(n, s)
+		case strings.HasPrefix(t, "//"):
+			x.text(n, strings.TrimPrefix(t, "//"))
+		case strings.HasPrefix(t, "/*"):
+			x.flush()
+			rest := t[2:]
+			if before, _, ok := strings.Cut(rest, "*/"); ok {
+				x.text(n, before)
+				x.flush()
+				continue
+			}
+			inBlock = true
+			x.text(n, rest)
+		default:
+			x.flush()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package flashcard
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	md := "# Go 面试\n\n## 切片扩容的规则？\n容量小于 256 时翻倍。\n\n### 源码\ngrowslice\n\n## 其他\n" +
+		"Q: map 是否并发安全\nA: 不是，\n需要加锁\n\n```go\n# 不是标题？\n```\n## map 怎么遍历有序？\n"
+	cards := Extract("Golang/a.md", md)
+	if len(cards) != 2 {
+		t.Fatalf("markdown cards: %+v", cards)
+	}
+	c := cards[0]
+	if c.Question != "切片扩容的规则？" || c.Line != 3 || c.Answer != "容量小于 256 时翻倍。\n\n### 源码\ngrowslice" {
+		t.Errorf("heading card: %+v", c)
+	}
+	c = cards[1]
+	if c.Question != "map 是否并发安全" || c.Line != 10 || c.Answer != "不是，\n需要加锁\n\n```go\n# 不是标题？\n```" {
+		t.Errorf("marker card: %+v", c)
+	}
+	if c.ID != CardID("Golang/a.md", "map 是否并发安全") || c.ID == cards[0].ID {
+		t.Errorf("id: %q", c.ID)
+	}
+
+	code := "package x\n\n/*\nQ：管理团队需要考虑什么\n答：\n1、目标\n2、分工\n*/\n" +
+		"// Q: defer 的执行顺序\n// A: 后进先出\nfunc f() {}\n// Q: 没有答案\nvar x = 1 // Q: 不是注释行\n"
+	cards = Extract("a.go", code)
+	if len(cards) != 2 {
+		t.Fatalf("code cards: %+v", cards)
+	}
+	if c := cards[0]; c.Question != "管理团队需要考虑什么" || c.Line != 4 || c.Answer != "1、目标\n2、分工" {
+		t.Errorf("block comment card: %+v", c)
+	}
+	if c := cards[1]; c.Question != "defer 的执行顺序" || c.Answer != "后进先出" {
+		t.Errorf("line comment card: %+v", c)
+	}
+}
+
+// testdata 中是按 Q:/A: 标记写成的代码笔记
+func TestExtractFixture(t *testing.T) {
+	b, err := os.ReadFile("testdata/scenario.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards := Extract("Golang/面试八股文/scenario.go", string(b))
+	if len(cards) != 2 {
+		t.Fatalf("cards: %+v", cards)
+	}
+	if c := cards[0]; c.Question != "管理一个5-10人团队，需要考虑哪些事情" || c.Line != 4 || c.Answer != "1、首先保证团队协作顺畅，成员有所成长\n首先明确核心目标与成功标准\n a、定义可量化的成功指标" {
+		t.Errorf("block comment card: %+v", c)
+	}
+	if c := cards[1]; c.Question != "接口压测需要关注哪些指标" || c.Answer != "qps、延迟分位数和错误率" {
+		t.Errorf("line comment card: %+v", c)
+	}
+}
+
+func TestReview(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	s := State{CardID: "c"}.Review(Good, now)
+	if s.Reps != 1 || s.Interval != 1 || s.EF != 2.5 || !s.Created.Equal(now) || !s.Due.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first review: %+v", s)
+	}
+	s = s.Review(Easy, now.AddDate(0, 0, 1))
+	if s.Reps != 2 || s.Interval != 6 || s.EF != 2.6 {
+		t.Fatalf("second review: %+v", s)
+	}
+	s = s.Review(Hard, now.AddDate(0, 0, 7))
+	if s.Reps != 3 || s.Interval != 16 || s.EF < 2.45 || s.EF > 2.47 {
+		t.Fatalf("third review: %+v", s)
+	}
+
+	later := now.AddDate(0, 0, 21)
+	s = s.Review(Again, later)
+	if s.Reps != 0 || s.Lapses != 1 || s.Interval != 1 || !s.Due.Equal(later.Add(relearn)) || !s.Created.Equal(now) {
+		t.Fatalf("lapse: %+v", s)
+	}
+	for range 10 {
+		s = s.Review(Again, later)
+	}
+	if s.EF != minEF {
+		t.Fatalf("ef floor: %v", s.EF)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	cards := []Card{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}, {ID: "f"}}
+	states := map[string]State{
+		"a": {CardID: "a", Interval: 30, Due: now.Add(-time.Hour), Created: now.AddDate(0, 0, -60), Reviewed: now.AddDate(0, 0, -30)},
+		"b": {CardID: "b", Interval: 1, Due: now.Add(-2 * time.Hour), Created: now.AddDate(0, 0, -1), Reviewed: now.AddDate(0, 0, -1), Lapses: 2},
+		"c": {CardID: "c", Interval: 1, Due: now.Add(time.Hour), Created: now.Add(-time.Hour), Reviewed: now.Add(-time.Hour)},
+		"x": {CardID: "x", Due: now.AddDate(0, 0, -1)},
+	}
+
+	q := Queue(cards, states, now, 2)
+	var ids string
+	for _, c := range q {
+		ids += c.ID
+	}
+	// c 是今天的新卡片，新卡片只剩一个名额
+	if ids != "bad" {
+		t.Fatalf("queue: %s", ids)
+	}
+
+	st := Summarize(cards, states, now)
+	want := Stats{Total: 6, New: 3, Due: 2, Learning: 2, Mature: 1, ReviewedToday: 1, Lapses: 2}
+	if st != want {
+		t.Fatalf("stats: %+v", st)
+	}
+}
@@ -0,0 +1,139 @@
+package flashcard
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Grade 回答质量，即 SM-2 的 0~5 分，低于 3 分算忘记
+type Grade int
+
+const (
+	Again Grade = 1 // 忘了
+	Hard  Grade = 3 // 想了很久才记起
+	Good  Grade = 4 // 记得
+	Easy  Grade = 5 // 很轻松
+)
+
+const (
+	initialEF = 2.5
+	minEF     = 1.3
+	// relearn 忘记的卡片在本次复习中再次出现的间隔
+	relearn = 10 * time.Minute
+	// matureDays 间隔达到这个天数的卡片算已掌握
+	matureDays = 21
+)
+
+// State 用户对一张卡片的复习进度
+type State struct {
+	CardID   string    `json:"card_id"`
+	EF       float64   `json:"ef"`       // 难度系数，越小复习越频繁
+	Interval int       `json:"interval"` // 复习间隔，天
+	Reps     int       `json:"reps"`     // 连续记住的次数
+	Lapses   int       `json:"lapses"`   // 忘记的次数
+	Due      time.Time `json:"due"`
+	Created  time.Time `json:"created"` // 第一次复习的时间
+	Reviewed time.Time `json:"reviewed"`
+}
+
+// Review 按 SM-2 计算一次复习后的进度：记住时间隔依次为 1 天、6 天、上次间隔乘以 EF，
+// 忘记时从头开始；到期时间取当天零点，同一天复习的卡片同时到期
+func (s State) Review(g Grade, now time.Time) State {
+	g = min(max(g, 0), 5)
+	if s.EF == 0 {
+		s.EF = initialEF
+		s.Created = now
+	}
+	s.Reviewed = now
+	if g < 3 {
+		s.Reps = 0
+		s.Lapses++
+		s.Interval = 1
+		s.Due = now.Add(relearn)
+	} else {
+		// 间隔使用调整前的 EF
+		switch s.Reps {
+		case 0:
+			s.Interval = 1
+		case 1:
+			s.Interval = 6
+		default:
+			s.Interval = int(math.Round(float64(s.Interval) * s.EF))
+		}
+		s.Reps++
+		s.Due = startOfDay(now).AddDate(0, 0, s.Interval)
+	}
+	q := float64(5 - g)
+	s.EF = max(minEF, s.EF+0.1-q*(0.08+q*0.02))
+	return s
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Queue 本次要复习的卡片：先是到期的卡片（按到期时间），再是新卡片（按笔记顺序），
+// 今天已经开始的新卡片计入 newLimit
+func Queue(cards []Card, states map[string]State, now time.Time, newLimit int) []Card {
+	var due, fresh []Card
+	newToday := 0
+	today := startOfDay(now)
+	for _, c := range cards {
+		s, ok := states[c.ID]
+		switch {
+		case !ok:
+			fresh = append(fresh, c)
+		case !s.Due.After(now):
+			due = append(due, c)
+		}
+		if ok && !s.Created.Before(today) {
+			newToday++
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return states[due[i].ID].Due.Before(states[due[j].ID].Due)
+	})
+	if n := max(newLimit-newToday, 0); len(fresh) > n {
+		fresh = fresh[:n]
+	}
+	return append(due, fresh...)
+}
+
+// Stats 复习进度统计
+type Stats struct {
+	Total         int `json:"total"`
+	New           int `json:"new"`            // 还没复习过
+	Due           int `json:"due"`            // 已到期
+	Learning      int `json:"learning"`       // 复习过但间隔不到 21 天
+	Mature        int `json:"mature"`         // 间隔达到 21 天
+	ReviewedToday int `json:"reviewed_today"` // 今天复习过的卡片
+	Lapses        int `json:"lapses"`         // 累计忘记的次数
+}
+
+// Summarize 统计 cards 的复习进度，不属于 cards 的状态（如已删除的卡片）不计入
+func Summarize(cards []Card, states map[string]State, now time.Time) Stats {
+	st := Stats{Total: len(cards)}
+	today := startOfDay(now)
+	for _, c := range cards {
+		s, ok := states[c.ID]
+		if !ok {
+			st.New++
+			continue
+		}
+		if !s.Due.After(now) {
+			st.Due++
+		}
+		if s.Interval >= matureDays {
+			st.Mature++
+		} else {
+			st.Learning++
+		}
+		if !s.Reviewed.Before(today) {
+			st.ReviewedToday++
+		}
+		st.Lapses += s.Lapses
+	}
+	return st
+}
@@ -0,0 +1,81 @@
+package flashcard
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Store 按用户保存复习进度
+type Store interface {
+	// States 用户所有卡片的进度，key 为卡片 ID
+	States(ctx context.Context, user string) (map[string]State, error)
+	Save(ctx context.Context, user string, s State) error
+}
+
+// stateRow flashcard_states 表，interval 是 MySQL 保留字，列名为 interval_days
+type stateRow struct {
+	User     string    `gorm:"column:user;primaryKey;size:128"`
+	CardID   string    `gorm:"column:card_id;primaryKey;size:32"`
+	EF       float64   `gorm:"column:ef;not null"`
+	Interval int       `gorm:"column:interval_days;not null"`
+	Reps     int       `gorm:"column:reps;not null"`
+	Lapses   int       `gorm:"column:lapses;not null"`
+	Due      time.Time `gorm:"column:due_at;not null"`
+	Created  time.Time `gorm:"column:created_at;not null"`
+	Reviewed time.Time `gorm:"column:reviewed_at;not null"`
+}
+
+func (stateRow) TableName() string {
+	return "flashcard_states"
+}
+
+// DB 基于 gorm 的 Store，用于 MySQL
+type DB struct {
+	db *gorm.DB
+}
+
+// NewDB 创建或升级 flashcard_states 表
+func NewDB(db *gorm.DB) (*DB, error) {
+	if err := db.AutoMigrate(&stateRow{}); err != nil {
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+func (s *DB) States(ctx context.Context, user string) (map[string]State, error) {
+	var rows []stateRow
+	if err := s.db.WithContext(ctx).Where("user = ?", user).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	states := make(map[string]State, len(rows))
+	for _, r := range rows {
+		states[r.CardID] = State{
+			CardID:   r.CardID,
+			EF:       r.EF,
+			Interval: r.Interval,
+			Reps:     r.Reps,
+			Lapses:   r.Lapses,
+			Due:      r.Due,
+			Created:  r.Created,
+			Reviewed: r.Reviewed,
+		}
+	}
+	return states, nil
+}
+
+func (s *DB) Save(ctx context.Context, user string, st State) error {
+	row := &stateRow{
+		User:     user,
+		CardID:   st.CardID,
+		EF:       st.EF,
+		Interval: st.Interval,
+		Reps:     st.Reps,
+		Lapses:   st.Lapses,
+		Due:      st.Due,
+		Created:  st.Created,
+		Reviewed: st.Reviewed,
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+}
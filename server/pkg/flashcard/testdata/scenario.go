@@ -0,0 +1,12 @@
+package 面试八股文
+
+/*
+Q: 管理一个5-10人团队，需要考虑哪些事情
+A: 1、首先保证团队协作顺畅，成员有所成长
+首先明确核心目标与成功标准
+ a、定义可量化的成功指标
+*/
+
+// Q: 接口压测需要关注哪些指标
+// A: qps、延迟分位数和错误率
+func main() {}
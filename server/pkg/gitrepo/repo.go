@@ -0,0 +1,148 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timeout = 10 * time.Second
+
+// Author 提交作者
+type Author struct {
+	Name  string
+	Email string
+}
+
+// Revision 文件的一个历史版本，Path 为该版本中文件相对仓库根目录的路径（跟踪重命名）
+type Revision struct {
+	Hash    string
+	Author  string
+	Email   string
+	Date    time.Time
+	Subject string
+	Path    string
+}
+
+// Repo 通过 git 命令行操作笔记仓库，dir 可以是仓库内的任意子目录
+type Repo struct {
+	dir  string
+	top  string // 仓库根目录
+	base string // dir 相对仓库根目录的前缀，如 "notefile/"
+	mu   sync.Mutex
+}
+
+// Open 打开 dir 所在的 git 仓库，不存在时在 dir 下初始化一个新仓库
+func Open(dir string) (*Repo, error) {
+	r := &Repo{dir: dir}
+	top, err := r.git(context.Background(), nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		if _, err := r.git(context.Background(), nil, "init", "-q"); err != nil {
+			return nil, err
+		}
+		if top, err = r.git(context.Background(), nil, "rev-parse", "--show-toplevel"); err != nil {
+			return nil, err
+		}
+	}
+	r.top = strings.TrimSpace(string(top))
+
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(r.top, real)
+	if err != nil {
+		return nil, err
+	}
+	if rel != "." {
+		r.base = filepath.ToSlash(rel) + "/"
+	}
+	return r, nil
+}
+
+func (r *Repo) git(ctx context.Context, env []string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotepath=off"}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Commit 提交 paths（相对 dir）上的全部改动，包括新增、删除和重命名，其他已暂存的改动不受影响
+func (r *Repo) Commit(ctx context.Context, author Author, message string, paths ...string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	args := append([]string{"add", "-A", "--"}, paths...)
+	if _, err := r.git(ctx, nil, args...); err != nil {
+		return "", err
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + author.Name, "GIT_AUTHOR_EMAIL=" + author.Email,
+		"GIT_COMMITTER_NAME=" + author.Name, "GIT_COMMITTER_EMAIL=" + author.Email,
+	}
+	args = append([]string{"commit", "-q", "--no-verify", "-m", message, "--only", "--"}, paths...)
+	if _, err := r.git(ctx, env, args...); err != nil {
+		return "", err
+	}
+	out, err := r.git(ctx, nil, "rev-parse", "HEAD")
+	return strings.TrimSpace(string(out)), err
+}
+
+const logFormat = "%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%s"
+
+// Log 返回文件的提交历史，从新到旧
+func (r *Repo) Log(ctx context.Context, path string, limit int) ([]Revision, error) {
+	args := []string{"log", "--follow", "--name-only", "--format=" + logFormat}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	out, err := r.git(ctx, nil, append(args, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []Revision
+	for _, rec := range strings.Split(string(out), "\x1e") {
+		lines := strings.Split(strings.TrimSpace(rec), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		rev := Revision{Hash: fields[0], Author: fields[1], Email: fields[2], Subject: fields[4]}
+		rev.Date, _ = time.Parse(time.RFC3339, fields[3])
+		for _, l := range lines[1:] {
+			if l = strings.TrimSpace(l); l != "" {
+				rev.Path = l
+			}
+		}
+		revs = append(revs, rev)
+	}
+	return revs, nil
+}
+
+// Show 读取某个版本中的文件内容，repoPath 为 Revision.Path（相对仓库根目录）
+func (r *Repo) Show(ctx context.Context, rev, repoPath string) ([]byte, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	return r.git(ctx, nil, "show", rev+":"+repoPath)
+}
+
+// RepoPath 把相对 dir 的路径转换为相对仓库根目录的路径
+func (r *Repo) RepoPath(path string) string {
+	return r.base + path
+}
@@ -0,0 +1,63 @@
+package gitrepo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitAndLog(t *testing.T) {
+	top := t.TempDir()
+	dir := filepath.Join(top, "notefile")
+	os.MkdirAll(dir, 0o755)
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	author := Author{Name: "alice", Email: "alice@localhost"}
+
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("v1\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "other.md"), []byte("untouched\n"), 0o644)
+	if _, err := r.Commit(ctx, author, "create a", "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("v2\n"), 0o644)
+	if _, err := r.Commit(ctx, author, "edit a", "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	os.Rename(filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md"))
+	if _, err := r.Commit(ctx, author, "rename a", "a.md", "b.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := r.Log(ctx, "b.md", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 3 || revs[0].Subject != "rename a" || revs[2].Author != "alice" {
+		t.Fatalf("unexpected log: %+v", revs)
+	}
+	if revs[0].Path != r.RepoPath("b.md") || revs[2].Path != r.RepoPath("a.md") {
+		t.Fatalf("paths not followed: %+v", revs)
+	}
+	b, err := r.Show(ctx, revs[2].Hash, revs[2].Path)
+	if err != nil || string(b) != "v1\n" {
+		t.Fatalf("show: %q %v", b, err)
+	}
+
+	// 未提交的其他文件不能被带进提交
+	if revs, _ := r.Log(ctx, "other.md", 0); len(revs) != 0 {
+		t.Fatalf("other.md was committed: %+v", revs)
+	}
+
+	os.Remove(filepath.Join(dir, "b.md"))
+	if _, err := r.Commit(ctx, author, "delete b", "b.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Show(ctx, "-h", "b.md"); err == nil {
+		t.Fatal("option-like revision accepted")
+	}
+}
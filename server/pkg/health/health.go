@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Check 一项就绪检查，如 MySQL 连接、Kafka broker
+type Check struct {
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+// Result 单项检查的结果
+type Result struct {
+	Name       string `json:"name"`
+	OK         bool   `json:"ok"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report 全部检查通过时 Status 为 ok，否则为 unavailable
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Run 并发执行全部检查，每项最多等待 timeout；不支持 ctx 的检查超时后不再等待，按失败处理
+func Run(ctx context.Context, timeout time.Duration, checks []Check) *Report {
+	rep := &Report{Status: "ok", Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rep.Checks[i] = probe(ctx, timeout, c)
+		}()
+	}
+	wg.Wait()
+	for _, r := range rep.Checks {
+		if !r.OK {
+			rep.Status = "unavailable"
+		}
+	}
+	return rep
+}
+
+func probe(ctx context.Context, timeout time.Duration, c Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				// 堆栈只记在服务端日志，不返回给未登录的客户端
+				log.Printf("health: %s panic: %v\n%s", c.Name, r, debug.Stack())
+				done <- errors.New("panic")
+			}
+		}()
+		done <- c.Probe(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	res := Result{Name: c.Name, OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Handler 返回检查结果的 JSON，有检查失败时状态码为 503
+func Handler(timeout time.Duration, checks []Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := Run(r.Context(), timeout, checks)
+		code := http.StatusOK
+		if rep.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(rep)
+	})
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	ok := Check{Name: "mysql:study", Probe: func(context.Context) error { return nil }}
+	down := Check{Name: "kafka:127.0.0.1:9092", Probe: func(context.Context) error { return errors.New("connection refused") }}
+	// 不检查 ctx 的探测超时后按失败处理，不阻塞整个请求
+	stuck := Check{Name: "stuck", Probe: func(context.Context) error { select {} }}
+	// panic 只返回通用的错误，不带 panic 内容和堆栈
+	broken := Check{Name: "broken", Probe: func(context.Context) error { panic("nil config at 0xdeadbeef") }}
+
+	serve := func(checks ...Check) (*httptest.ResponseRecorder, *Report) {
+		w := httptest.NewRecorder()
+		Handler(50*time.Millisecond, checks).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		rep := &Report{}
+		if err := json.Unmarshal(w.Body.Bytes(), rep); err != nil {
+			t.Fatal(err)
+		}
+		return w, rep
+	}
+
+	w, rep := serve(ok)
+	if w.Code != http.StatusOK || rep.Status != "ok" || len(rep.Checks) != 1 || !rep.Checks[0].OK {
+		t.Fatalf("ready: %d %s", w.Code, w.Body)
+	}
+	if w, _ := serve(); w.Code != http.StatusOK {
+		t.Errorf("no checks: %d", w.Code)
+	}
+
+	start := time.Now()
+	w, rep = serve(ok, down, stuck, broken)
+	if w.Code != http.StatusServiceUnavailable || rep.Status != "unavailable" {
+		t.Fatalf("not ready: %d %s", w.Code, w.Body)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("stuck probe blocked the request")
+	}
+	got := rep.Checks
+	if got[0].Name != "mysql:study" || !got[0].OK || got[1].Error != "connection refused" || got[2].Error != context.DeadlineExceeded.Error() || got[3].Error != "panic" {
+		t.Errorf("checks: %+v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package highlight
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+var goBuiltins = map[string]bool{
+	"bool": true, "byte": true, "complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"uintptr": true, "any": true, "comparable": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true, "delete": true,
+	"imag": true, "len": true, "make": true, "max": true, "min": true, "new": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true,
+}
+
+// lexGo 使用标准库 go/scanner 切分 Go 源码，语法错误不影响输出
+func lexGo(src string) []Span {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(token.Position, string) {}, scanner.ScanComments)
+
+	var (
+		spans []Span
+		last  int
+	)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// 自动插入的分号不对应源码
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+		start := file.Offset(pos)
+		if start < last {
+			continue
+		}
+		end := goTokenEnd(src, start, tok, lit)
+
+		var class string
+		switch {
+		case tok.IsKeyword():
+			class = Keyword
+		case tok == token.IDENT && goBuiltins[lit]:
+			class = Builtin
+		case tok == token.STRING || tok == token.CHAR:
+			class = String
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = Number
+		case tok == token.COMMENT:
+			class = Comment
+		}
+		if start > last {
+			spans = append(spans, Span{Text: src[last:start]})
+		}
+		spans = append(spans, Span{Class: class, Text: src[start:end]})
+		last = end
+	}
+	if last < len(src) {
+		spans = append(spans, Span{Text: src[last:]})
+	}
+	return spans
+}
+
+// goTokenEnd 计算 token 在源码中的结束位置；注释和原始字符串的 lit 去掉了 \r，不能直接用长度
+func goTokenEnd(src string, start int, tok token.Token, lit string) int {
+	end := start + len(lit)
+	switch {
+	case tok == token.COMMENT && strings.HasPrefix(src[start:], "//"):
+		if i := strings.IndexByte(src[start:], '\n'); i >= 0 {
+			end = start + i
+		} else {
+			end = len(src)
+		}
+		if end > start && src[end-1] == '\r' {
+			end--
+		}
+	case tok == token.COMMENT:
+		if i := strings.Index(src[start+2:], "*/"); i >= 0 {
+			end = start + 2 + i + 2
+		} else {
+			end = len(src)
+		}
+	case tok == token.STRING && strings.HasPrefix(src[start:], "`"):
+		if i := strings.IndexByte(src[start+1:], '`'); i >= 0 {
+			end = start + 1 + i + 1
+		} else {
+			end = len(src)
+		}
+	case lit == "":
+		end = start + len(tok.String())
+	}
+	if end > len(src) {
+		end = len(src)
+	}
+	return end
+}
@@ -0,0 +1,118 @@
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"path"
+	"strings"
+)
+
+// Span 一段带样式类名的源码，Class 为空表示普通文本
+type Span struct {
+	Class string
+	Text  string
+}
+
+const (
+	Keyword = "kw"
+	Builtin = "bi"
+	String  = "str"
+	Number  = "num"
+	Comment = "com"
+	Key     = "key" // YAML 键
+	Var     = "var" // shell 变量
+)
+
+var lexers = map[string]func(src string) []Span{
+	"go":    lexGo,
+	"yaml":  lexYAML,
+	"shell": lexShell,
+	"sql":   lexSQL,
+}
+
+var aliases = map[string]string{
+	"golang": "go",
+	"yml":    "yaml",
+	"sh":     "shell",
+	"bash":   "shell",
+	"zsh":    "shell",
+	"mysql":  "sql",
+}
+
+// Lang 根据文件名推断语言，无法识别返回 ""
+func Lang(name string) string {
+	base := strings.ToLower(path.Base(name))
+	switch base {
+	case "makefile", "dockerfile", ".env", ".bashrc", ".zshrc":
+		return "shell"
+	}
+	return Normalize(strings.TrimPrefix(path.Ext(base), "."))
+}
+
+// Normalize 规范化语言名（如 Markdown 代码块的 ```golang），不支持的语言返回 ""
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if a, ok := aliases[lang]; ok {
+		lang = a
+	}
+	if _, ok := lexers[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+// Tokenize 按语言切分源码，未知语言整体作为普通文本
+func Tokenize(lang, src string) []Span {
+	if lex, ok := lexers[Normalize(lang)]; ok {
+		return lex(src)
+	}
+	return []Span{{Text: src}}
+}
+
+// Inline 输出高亮后的 HTML 片段，用于放进 <pre><code>
+func Inline(lang, src string) template.HTML {
+	sb := &strings.Builder{}
+	for _, s := range Tokenize(lang, src) {
+		writeSpan(sb, s.Class, s.Text)
+	}
+	return template.HTML(sb.String())
+}
+
+// Render 输出带行号和行锚点（#L42）的表格，跨行的注释、字符串按行拆开
+func Render(lang, src string) template.HTML {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.TrimSuffix(src, "\n")
+
+	sb := &strings.Builder{}
+	sb.WriteString(`<table class="code"><tbody>`)
+	line := 1
+	startLine := func() {
+		fmt.Fprintf(sb, `<tr id="L%d"><td class="ln"><a href="#L%d">%d</a></td><td class="line">`, line, line, line)
+	}
+	startLine()
+	for _, s := range Tokenize(lang, src) {
+		parts := strings.Split(s.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				sb.WriteString("</td></tr>\n")
+				line++
+				startLine()
+			}
+			writeSpan(sb, s.Class, part)
+		}
+	}
+	sb.WriteString("</td></tr>\n</tbody></table>")
+	return template.HTML(sb.String())
+}
+
+func writeSpan(sb *strings.Builder, class, text string) {
+	if text == "" {
+		return
+	}
+	if class == "" {
+		sb.WriteString(html.EscapeString(text))
+		return
+	}
+	fmt.Fprintf(sb, `<span class="%s">%s</span>`, class, html.EscapeString(text))
+}
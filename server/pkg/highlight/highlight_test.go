@@ -0,0 +1,94 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func classes(spans []Span) map[string][]string {
+	m := make(map[string][]string)
+	for _, s := range spans {
+		if s.Class != "" {
+			m[s.Class] = append(m[s.Class], s.Text)
+		}
+	}
+	return m
+}
+
+func TestLang(t *testing.T) {
+	for name, want := range map[string]string{
+		"Golang/yingyong/context.go":       "go",
+		"Docker/docker-compose-mysql.yaml": "yaml",
+		"deploy.SH":                        "shell",
+		"Makefile":                         "shell",
+		"init.sql":                         "sql",
+		"Docker/compose.text":              "",
+	} {
+		if got := Lang(name); got != want {
+			t.Errorf("Lang(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	src := "package main\r\n\r\n// 注释\r\nfunc main() {\r\n\ts := `a\r\nb` + \"x\"\r\n\t_ = len(s) + 42 /* c */\r\n}\r\n"
+	spans := Tokenize("go", src)
+
+	var sb strings.Builder
+	for _, s := range spans {
+		sb.WriteString(s.Text)
+	}
+	if sb.String() != src {
+		t.Fatalf("spans do not cover source:\n%q", sb.String())
+	}
+
+	c := classes(spans)
+	if strings.Join(c[Keyword], ",") != "package,func" {
+		t.Errorf("keywords: %v", c[Keyword])
+	}
+	if strings.Join(c[Comment], ",") != "// 注释,/* c */" {
+		t.Errorf("comments: %q", c[Comment])
+	}
+	if strings.Join(c[String], ",") != "`a\r\nb`,\"x\"" {
+		t.Errorf("strings: %q", c[String])
+	}
+	if strings.Join(c[Builtin], ",") != "len" || strings.Join(c[Number], ",") != "42" {
+		t.Errorf("builtins/numbers: %v %v", c[Builtin], c[Number])
+	}
+}
+
+func TestOtherLexers(t *testing.T) {
+	c := classes(Tokenize("yaml", "# db\nservices:\n  mysql:\n    image: \"mysql:8\" # tag\n    ports:\n      - 3306\n    restart: true\n"))
+	if strings.Join(c[Key], ",") != "services,mysql,image,ports,restart" {
+		t.Errorf("yaml keys: %v", c[Key])
+	}
+	if strings.Join(c[Number], ",") != "true" || strings.Join(c[String], ",") != `"mysql:8"` {
+		t.Errorf("yaml values: %v %v", c[Number], c[String])
+	}
+
+	c = classes(Tokenize("shell", "export A=1 # set\nif [ -n \"$A\" ]; then echo ${A}; fi\n"))
+	if strings.Join(c[Keyword], ",") != "export,if,then,echo,fi" || strings.Join(c[Var], ",") != "${A}" {
+		t.Errorf("shell: %v", c)
+	}
+
+	c = classes(Tokenize("sql", "select count(*) from `user` where name = 'it''s' -- x\n"))
+	if strings.Join(c[Keyword], ",") != "select,from,where" || strings.Join(c[Builtin], ",") != "count" {
+		t.Errorf("sql: %v", c)
+	}
+}
+
+func TestRender(t *testing.T) {
+	out := string(Render("go", "// a\n/* b\nc */\nx := \"<script>\"\n"))
+	for _, want := range []string{
+		`<tr id="L1"><td class="ln"><a href="#L1">1</a></td><td class="line"><span class="com">// a</span></td></tr>`,
+		`<tr id="L3"><td class="ln"><a href="#L3">3</a></td><td class="line"><span class="com">c */</span></td></tr>`,
+		`&lt;script&gt;`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `id="L5"`) {
+		t.Errorf("trailing newline should not add a line")
+	}
+}
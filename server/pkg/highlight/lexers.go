@@ -0,0 +1,268 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// spans 追加时合并相邻的同类片段
+type spans []Span
+
+func (s *spans) add(class, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(*s); n > 0 && (*s)[n-1].Class == class {
+		(*s)[n-1].Text += text
+		return
+	}
+	*s = append(*s, Span{Class: class, Text: text})
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || ('0' <= c && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// quoted 返回从 i 处引号开始的字符串结束位置，backslash 表示是否支持 \ 转义
+func quoted(src string, i int, backslash bool) int {
+	q := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch {
+		case backslash && src[j] == '\\':
+			j++
+		case src[j] == q:
+			return j + 1
+		}
+	}
+	return len(src)
+}
+
+func lineEnd(src string, i int) int {
+	if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+		return i + j
+	}
+	return len(src)
+}
+
+var (
+	yamlKey    = regexp.MustCompile(`^(\s*(?:-\s+)*)("[^"]*"|'[^']*'|[^\s#'"\-][^:#]*?|-[^\s:#][^:#]*?)(\s*:)(\s|$)`)
+	yamlScalar = regexp.MustCompile(`^(?:[-+]?(?:\d[\d_]*(?:\.\d*)?(?:[eE][-+]?\d+)?|0x[0-9a-fA-F]+|\.inf|\.nan)|true|false|yes|no|on|off|null|~)$`)
+)
+
+// lexYAML 按行处理：注释、文档分隔符、键、引号字符串和常见标量
+func lexYAML(src string) []Span {
+	var out spans
+	for len(src) > 0 {
+		end := lineEnd(src, 0)
+		line := src[:end]
+		lexYAMLLine(&out, line)
+		if end < len(src) {
+			out.add("", "\n")
+			end++
+		}
+		src = src[end:]
+	}
+	return out
+}
+
+func lexYAMLLine(out *spans, line string) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		out.add(Comment, line)
+		return
+	case trimmed == "---" || trimmed == "...":
+		out.add(Keyword, line)
+		return
+	}
+
+	rest := line
+	if m := yamlKey.FindStringSubmatchIndex(line); m != nil {
+		out.add("", line[m[2]:m[3]])
+		out.add(Key, line[m[4]:m[5]])
+		out.add("", line[m[6]:m[7]])
+		rest = line[m[7]:]
+	}
+
+	value := strings.TrimSpace(rest)
+	if i := strings.Index(rest, " #"); i >= 0 && !strings.ContainsAny(rest[:i], `"'`) {
+		value = strings.TrimSpace(rest[:i])
+	}
+	if yamlScalar.MatchString(strings.ToLower(value)) {
+		i := strings.Index(rest, value)
+		out.add("", rest[:i])
+		out.add(Number, value)
+		rest = rest[i+len(value):]
+	}
+
+	for i := 0; i < len(rest); {
+		c := rest[i]
+		switch {
+		case c == '"' || c == '\'':
+			j := quoted(rest, i, c == '"')
+			out.add(String, rest[i:j])
+			i = j
+		case c == '#' && (i == 0 || rest[i-1] == ' ' || rest[i-1] == '\t'):
+			out.add(Comment, rest[i:])
+			i = len(rest)
+		case (c == '&' || c == '*') && i+1 < len(rest) && isIdent(rest[i+1]):
+			j := i + 1
+			for j < len(rest) && (isIdent(rest[j]) || rest[j] == '-') {
+				j++
+			}
+			out.add(Var, rest[i:j])
+			i = j
+		default:
+			out.add("", rest[i:i+1])
+			i++
+		}
+	}
+}
+
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "for": true, "in": true, "do": true,
+	"done": true, "while": true, "until": true, "case": true, "esac": true, "function": true, "return": true,
+	"export": true, "local": true, "readonly": true, "set": true, "unset": true, "source": true, "exit": true,
+	"cd": true, "echo": true, "sudo": true,
+}
+
+// lexShell 识别注释、引号字符串、$变量和常见关键字
+func lexShell(src string) []Span {
+	var out spans
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '#' && (i == 0 || strings.IndexByte(" \t\n;", src[i-1]) >= 0):
+			j := lineEnd(src, i)
+			out.add(Comment, src[i:j])
+			i = j
+		case c == '\'' || c == '"':
+			j := quoted(src, i, c == '"')
+			out.add(String, src[i:j])
+			i = j
+		case c == '\\' && i+1 < len(src):
+			out.add("", src[i:i+2])
+			i += 2
+		case c == '$' && i+1 < len(src):
+			j := i + 1
+			switch {
+			case src[j] == '{' || src[j] == '(':
+				closer := byte('}')
+				if src[j] == '(' {
+					closer = ')'
+				}
+				if k := strings.IndexByte(src[j:], closer); k >= 0 {
+					j += k + 1
+				} else {
+					j = len(src)
+				}
+			case isIdentStart(src[j]):
+				for j < len(src) && isIdent(src[j]) {
+					j++
+				}
+			case strings.IndexByte("0123456789@#?$!*-", src[j]) >= 0:
+				j++
+			}
+			out.add(Var, src[i:j])
+			i = j
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && (isIdent(src[j]) || src[j] == '-') {
+				j++
+			}
+			word := src[i:j]
+			if shellKeywords[word] && (i == 0 || !isIdent(src[i-1]) && src[i-1] != '-') {
+				out.add(Keyword, word)
+			} else {
+				out.add("", word)
+			}
+			i = j
+		default:
+			out.add("", src[i:i+1])
+			i++
+		}
+	}
+	return out
+}
+
+var sqlKeywords = toSet("SELECT FROM WHERE AND OR NOT IN IS NULL AS ON JOIN LEFT RIGHT INNER OUTER FULL CROSS " +
+	"GROUP BY ORDER HAVING LIMIT OFFSET UNION ALL DISTINCT INSERT INTO VALUES UPDATE SET DELETE CREATE " +
+	"TABLE INDEX VIEW DATABASE SCHEMA DROP ALTER ADD COLUMN PRIMARY KEY FOREIGN REFERENCES UNIQUE DEFAULT " +
+	"CONSTRAINT CHECK IF EXISTS CASE WHEN THEN ELSE END BEGIN COMMIT ROLLBACK TRANSACTION LIKE BETWEEN " +
+	"ASC DESC WITH RECURSIVE EXPLAIN USE SHOW GRANT REVOKE TRUNCATE REPLACE AUTO_INCREMENT ENGINE CHARSET " +
+	"COMMENT UNSIGNED FOR LOCK SHARE MODE TRUE FALSE")
+
+var sqlBuiltins = toSet("INT INTEGER BIGINT SMALLINT TINYINT DECIMAL NUMERIC FLOAT DOUBLE REAL CHAR VARCHAR " +
+	"TEXT LONGTEXT BLOB DATE DATETIME TIMESTAMP TIME BOOLEAN BOOL JSON " +
+	"COUNT SUM AVG MIN MAX NOW COALESCE IFNULL CONCAT SUBSTRING LENGTH LOWER UPPER CAST CONVERT")
+
+func toSet(words string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		m[w] = true
+	}
+	return m
+}
+
+// lexSQL 识别 -- # /* */ 注释、字符串、数字以及大小写不敏感的关键字
+func lexSQL(src string) []Span {
+	var out spans
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "--") || c == '#':
+			j := lineEnd(src, i)
+			out.add(Comment, src[i:j])
+			i = j
+		case strings.HasPrefix(src[i:], "/*"):
+			j := len(src)
+			if k := strings.Index(src[i+2:], "*/"); k >= 0 {
+				j = i + 2 + k + 2
+			}
+			out.add(Comment, src[i:j])
+			i = j
+		case c == '\'' || c == '"':
+			j := quoted(src, i, true)
+			out.add(String, src[i:j])
+			i = j
+		case c == '`':
+			j := quoted(src, i, false)
+			out.add("", src[i:j])
+			i = j
+		case isDigit(c) && (i == 0 || !isIdent(src[i-1])):
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.') {
+				j++
+			}
+			out.add(Number, src[i:j])
+			i = j
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && isIdent(src[j]) {
+				j++
+			}
+			word := src[i:j]
+			switch upper := strings.ToUpper(word); {
+			case sqlKeywords[upper]:
+				out.add(Keyword, word)
+			case sqlBuiltins[upper]:
+				out.add(Builtin, word)
+			default:
+				out.add("", word)
+			}
+			i = j
+		default:
+			out.add("", src[i:i+1])
+			i++
+		}
+	}
+	return out
+}
@@ -6,10 +6,9 @@ import (
 	"github.com/segmentio/kafka-go"
 	"log"
 	"node/conf"
-	"os"
-	"os/signal"
+	"node/pkg/lifecycle"
 	"sync"
-	"syscall"
+	"time"
 )
 
 // MultiTopicConsumerManager 多topic消费者管理器
@@ -73,7 +72,8 @@ func (m *MultiTopicConsumerManager) AddConsumer(cfg *conf.KafkaConfig, topic, gr
 	return nil
 }
 
-// Start 启动所有消费者并等待中断信号
+// Start 标记管理器已启动后立即返回，消费者在 AddConsumer 时已经开始消费；
+// 信号由调用方处理（见 lifecycle.App），退出时调用 StopAll
 func (m *MultiTopicConsumerManager) Start() error {
 	m.mu.Lock()
 	if m.started {
@@ -89,14 +89,6 @@ func (m *MultiTopicConsumerManager) Start() error {
 	m.mu.Unlock()
 
 	log.Printf("启动 %d 个消费者...", consumerCount)
-
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	<-quit
-
-	// 统一停止所有消费者
-	m.StopAll()
 	return nil
 }
 
@@ -202,8 +194,17 @@ func ExampleMultiTopicConsumer() {
 		log.Fatal("添加 topic3 消费者失败:", err)
 	}
 
-	// 启动并等待，按 Ctrl+C 时会统一停止所有消费者
-	if err := manager.Start(); err != nil {
+	// 按 Ctrl+C 时统一停止所有消费者
+	app := lifecycle.New(10 * time.Second)
+	app.Append(lifecycle.Hook{
+		Name:  "consumers",
+		Start: func(context.Context) error { return manager.Start() },
+		Stop: func(context.Context) error {
+			manager.StopAll()
+			return nil
+		},
+	})
+	if err := app.Run(context.Background()); err != nil {
 		log.Fatal("启动管理器失败:", err)
 	}
 }
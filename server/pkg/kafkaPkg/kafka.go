@@ -1,9 +1,24 @@
 package kafkaPkg
 
-import "node/conf"
+import (
+	"context"
+	"errors"
+	"node/conf"
+	"node/pkg/metrics"
+)
 
 var (
 	gProducer *kfProducer
+
+	errProducerClosed = errors.New("kafka producer closed")
+
+	publishDuration = metrics.NewHistogram("kafka_publish_duration_seconds", "Time from enqueueing a message to the broker acknowledging it.", metrics.DefBuckets, "topic")
+	publishedTotal  = metrics.NewCounter("kafka_published_messages_total", "Messages acknowledged by the broker.", "topic")
+	publishErrors   = metrics.NewCounter("kafka_publish_errors_total", "Messages that failed to publish.", "topic")
+	consumedTotal   = metrics.NewCounter("kafka_consumed_messages_total", "Messages fetched by consumers.", "topic", "group")
+	consumeErrors   = metrics.NewCounter("kafka_consume_errors_total", "Messages whose handler still failed after retries.", "topic", "group")
+	consumeDuration = metrics.NewHistogram("kafka_consume_duration_seconds", "Handler time per message, including retries.", metrics.DefBuckets, "topic", "group")
+	consumerLag     = metrics.NewGauge("kafka_consumer_lag", "Messages behind the partition high watermark after the last fetch.", "topic", "group", "partition")
 )
 
 func InitKafka(cfg *conf.KafkaConfig) {
@@ -12,3 +27,29 @@ func InitKafka(cfg *conf.KafkaConfig) {
 	}
 	gProducer = NewKfProducer(cfg)
 }
+
+// PingBroker 连接单个 broker 并读取集群的 broker 列表，用于就绪检查
+func PingBroker(ctx context.Context, cfg *conf.KafkaConfig, addr string) error {
+	dialer, err := NewKafkaDialer(cfg)
+	if err != nil {
+		return err
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	_, err = conn.Brokers()
+	return err
+}
+
+// Close 关闭 InitKafka 创建的生产者，退出前调用，避免异步发送中的消息丢失
+func Close() error {
+	if gProducer == nil {
+		return nil
+	}
+	return gProducer.close()
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/segmentio/kafka-go/sasl/scram"
 	"log"
 	"node/conf"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -146,7 +147,11 @@ func NewConsumerWithContext(parentCtx context.Context, cfg *conf.KafkaConfig, to
 						continue
 					}
 
+					consumedTotal.With(topic, groupID).Inc()
+					consumerLag.With(topic, groupID, strconv.Itoa(msg.Partition)).Set(float64(msg.HighWaterMark - msg.Offset - 1))
+
 					// 处理消息,支持重试
+					start := time.Now()
 					var handlerErr error
 					for retry := 0; retry < MaxRetryCount; retry++ {
 						handlerErr = handler(&msg)
@@ -163,7 +168,9 @@ func NewConsumerWithContext(parentCtx context.Context, cfg *conf.KafkaConfig, to
 					}
 
 					// 记录最终处理结果
+					consumeDuration.With(topic, groupID).Observe(time.Since(start).Seconds())
 					if handlerErr != nil {
+						consumeErrors.With(topic, groupID).Inc()
 						log.Printf("[消费者-%d] 消息处理最终失败,已达最大重试次数: offset=%d, partition=%d, error=%v",
 							consumerId, msg.Offset, msg.Partition, handlerErr)
 					}
@@ -3,6 +3,7 @@ package kafkaPkg
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/scram"
@@ -16,6 +17,7 @@ type kfProducer struct {
 	cfg     conf.KafkaConfig
 	writers map[string]*kafka.Writer
 	mu      sync.Mutex
+	closed  bool
 }
 
 func NewKfProducer(cfg *conf.KafkaConfig) *kfProducer {
@@ -29,9 +31,14 @@ func NewKafkaWriter(topic string, cfg *conf.KafkaConfig) (w *kafka.Writer, err e
 	fmt.Println("NewKafkaWriter", cfg)
 	//可选设置回调函数
 	completionFunc := func(msgs []kafka.Message, err error) {
+		for _, m := range msgs {
+			publishDuration.With(topic).Observe(time.Since(m.Time).Seconds())
+		}
 		if err != nil {
+			publishErrors.With(topic).Add(float64(len(msgs)))
 			return
 		}
+		publishedTotal.With(topic).Add(float64(len(msgs)))
 		latestOffset := msgs[len(msgs)-1].Offset
 		log.Printf("投递成功，最新 Offset: %d, 消息数: %d", latestOffset, len(msgs))
 	}
@@ -76,6 +83,9 @@ func (p *kfProducer) getWriter(topic string) (w *kafka.Writer, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.closed {
+		return nil, errProducerClosed
+	}
 	w, ok := p.writers[topic]
 	if ok {
 		return w, nil
@@ -97,6 +107,22 @@ func (p *kfProducer) getWriter(topic string) (w *kafka.Writer, err error) {
 	return w, nil
 }
 
+// close 关闭全部 writer，异步模式下会等待缓冲中的消息发送完成；之后的发送直接返回错误
+func (p *kfProducer) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	var errs []error
+	for topic, w := range p.writers {
+		if err := w.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close writer %s: %w", topic, err))
+		}
+		delete(p.writers, topic)
+	}
+	return errors.Join(errs...)
+}
+
 /**
  * 创建topic
  * partition: 分区数 只能增加，不能减少，(若需减少，需要重建Topic) （建议每个broker承载100-200个分区）
@@ -164,17 +190,25 @@ func (p *kfProducer) createTopic(ctx context.Context, topic string) (err error)
 func (p *kfProducer) publish(topic string, key, value []byte, headers []kafka.Header) error {
 	w, err := p.getWriter(topic)
 	if err != nil {
+		publishErrors.With(topic).Inc()
 		err = fmt.Errorf("get kafka writer err:%w", err)
 		return err
 	}
 
+	// Time 是消息的创建时间，发送完成的回调用它计算耗时
 	msg := kafka.Message{
 		Key:     key,
 		Value:   value,
 		Headers: headers,
+		Time:    time.Now(),
 	}
 	err = w.WriteMessages(context.Background(), msg)
 	if err != nil {
+		// 进入批次后失败的消息（WriteErrors）已由 Completion 回调计数，这里只计入批次之前的错误
+		var werr kafka.WriteErrors
+		if !errors.As(err, &werr) {
+			publishErrors.With(topic).Inc()
+		}
 		err = fmt.Errorf("write message err:%w", err)
 	}
 	return err
@@ -190,9 +224,9 @@ func PublishRetry(topic string, key, value []byte, headers []kafka.Header, retry
 		if err == nil {
 			return
 		}
-		fmt.Sprintln(fmt.Sprintf("PublishRetry topic:%s retry:%d error:%v", topic, i, err))
+		log.Printf("PublishRetry topic:%s retry:%d error:%v", topic, i, err)
 	}
-	err = fmt.Errorf("try max but failed")
+	err = fmt.Errorf("try max but failed: %w", err)
 	return
 }
 
@@ -0,0 +1,54 @@
+package kafkaPkg
+
+import (
+	"context"
+	"errors"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"net"
+	"node/conf"
+	"node/pkg/metrics"
+	"strings"
+	"testing"
+)
+
+// brokenBroker 元数据请求正常返回一个分区，发送消息全部失败
+type brokenBroker struct{}
+
+func (brokenBroker) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	if r, ok := req.(*metadata.Request); ok {
+		return &metadata.Response{
+			Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "127.0.0.1", Port: 9092}},
+			Topics: []metadata.ResponseTopic{{
+				Name:       r.TopicNames[0],
+				Partitions: []metadata.ResponsePartition{{PartitionIndex: 0, LeaderID: 1}},
+			}},
+		}, nil
+	}
+	return nil, errors.New("broker down")
+}
+
+func TestPublishErrorCountedOnce(t *testing.T) {
+	const topic = "publish-error-test"
+	cfg := &conf.KafkaConfig{Brokers: []string{"127.0.0.1:9092"}}
+	w, err := NewKafkaWriter(topic, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同步发送时 WriteMessages 返回错误，Completion 回调也收到同一个错误
+	w.Async = false
+	w.MaxAttempts = 1
+	w.Transport = brokenBroker{}
+	p := NewKfProducer(cfg)
+	p.writers[topic] = w
+	defer p.close()
+
+	if err := p.publish(topic, nil, []byte("x"), nil); err == nil {
+		t.Fatal("publish to a broken broker succeeded")
+	}
+	buf := &strings.Builder{}
+	metrics.Default.WriteTo(buf)
+	if want := `kafka_publish_errors_total{topic="publish-error-test"} 1`; !strings.Contains(buf.String(), want+"\n") {
+		t.Errorf("missing %s in\n%s", want, buf)
+	}
+}
@@ -0,0 +1,283 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Hook 一个组件的启动和停止。Start 按依赖顺序执行，Stop 按相反顺序执行，
+// 依赖的组件总是比自己先启动、后停止；Start 的 ctx 只在启动期间有效，不要保存
+type Hook struct {
+	Name      string
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+}
+
+// App 管理进程中各个组件的生命周期：HTTP 服务、Kafka 生产者和消费者、MySQL 连接池、后台协程等
+type App struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started []Hook
+	err     error
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// New shutdownTimeout 是停止全部组件的总时限，超时后不再等待未退出的组件
+func New(shutdownTimeout time.Duration) *App {
+	return &App{timeout: shutdownTimeout, done: make(chan struct{})}
+}
+
+// Append 注册组件，必须在 Start 之前调用
+func (a *App) Append(h Hook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = append(a.hooks, h)
+}
+
+// Go 注册一个后台协程：启动时在新的 goroutine 中执行 run，停止时取消 ctx 并等待 run 返回。
+// run 提前返回时整个 App 开始退出，返回的错误作为 Run 的结果；一次性的命令也用它执行
+func (a *App) Go(name string, run func(ctx context.Context) error, deps ...string) {
+	var (
+		cancel context.CancelFunc
+		exited chan struct{}
+		runErr error
+	)
+	a.Append(Hook{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			exited = make(chan struct{})
+			go func() {
+				defer close(exited)
+				err := run(ctx)
+				if ctx.Err() != nil {
+					if !errors.Is(err, context.Canceled) {
+						runErr = err
+					}
+					return
+				}
+				if err != nil {
+					err = fmt.Errorf("%s: %w", name, err)
+				}
+				a.Fail(err)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-exited:
+				return runErr
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Serve 注册 HTTP 服务：启动时先监听端口，端口被占用等错误直接导致启动失败；
+// 停止时调用 Shutdown 等待进行中的请求完成。srv.TLSConfig 中有证书时使用 HTTPS
+func (a *App) Serve(name string, srv *http.Server, deps ...string) {
+	a.Append(Hook{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(ctx context.Context) error {
+			ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			tls := srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 || srv.TLSConfig.GetCertificate != nil)
+			log.Println("listen:", ln.Addr(), "tls:", tls)
+			go func() {
+				var err error
+				if tls {
+					err = srv.ServeTLS(ln, "", "")
+				} else {
+					err = srv.Serve(ln)
+				}
+				if !errors.Is(err, http.ErrServerClosed) {
+					a.Fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	})
+}
+
+// Fail 组件运行中出错时调用，App 随即开始退出；只记录第一个错误，err 为 nil 时正常退出
+func (a *App) Fail(err error) {
+	a.mu.Lock()
+	if a.err == nil {
+		a.err = err
+	}
+	a.mu.Unlock()
+	a.doneOnce.Do(func() { close(a.done) })
+}
+
+// Done 调用 Fail 或 Go 注册的协程提前返回后关闭
+func (a *App) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err Fail 记录的错误
+func (a *App) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Start 按依赖顺序启动组件，没有依赖关系的按注册顺序；某个组件启动失败时，
+// 在时限内停止已经启动的组件后返回错误
+func (a *App) Start(ctx context.Context) error {
+	a.mu.Lock()
+	hooks, err := order(a.hooks)
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, h := range hooks {
+		if h.Start != nil {
+			begin := time.Now()
+			if err := h.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				stopCtx, cancel := context.WithTimeout(context.Background(), a.timeout)
+				defer cancel()
+				return errors.Join(err, a.Stop(stopCtx))
+			}
+			log.Printf("start: %s (%s)", h.Name, time.Since(begin).Round(time.Millisecond))
+		}
+		a.mu.Lock()
+		a.started = append(a.started, h)
+		a.mu.Unlock()
+	}
+	return nil
+}
+
+// abandonGrace 停止时限到期后，每个组件的 Stop 最多再等待的时间
+const abandonGrace = 100 * time.Millisecond
+
+// Stop 按启动的相反顺序停止组件；ctx 到期后不再等待正在停止的组件，
+// 剩下的组件仍会收到已到期的 ctx，尽量释放连接和文件
+func (a *App) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	started := a.started
+	a.started = nil
+	a.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		h := started[i]
+		if h.Stop == nil {
+			continue
+		}
+		begin := time.Now()
+		stopped := make(chan error, 1)
+		go func() { stopped <- h.Stop(ctx) }()
+		var err error
+		select {
+		case err = <-stopped:
+		case <-ctx.Done():
+			// 到期后仍给每个组件一小段时间，关闭文件、连接这类立即完成的操作不被跳过
+			select {
+			case err = <-stopped:
+			case <-time.After(abandonGrace):
+				err = fmt.Errorf("abandoned: %w", ctx.Err())
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			log.Printf("stop: %s: %v", h.Name, err)
+			continue
+		}
+		log.Printf("stop: %s (%s)", h.Name, time.Since(begin).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// Run 启动全部组件，收到 SIGINT、SIGTERM、SIGQUIT 或调用 Fail 后在时限内停止；
+// 停止期间再次收到信号时进程直接退出
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		log.Println("shutdown:", context.Cause(ctx))
+	case <-a.done:
+	}
+	// 恢复信号的默认处理，停止卡住时可以再按一次 Ctrl+C
+	stop()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	return errors.Join(a.Err(), a.Stop(stopCtx))
+}
+
+// order 按依赖关系排序：每一轮取注册顺序中第一个依赖都已排好的组件
+func order(hooks []Hook) ([]Hook, error) {
+	names := make(map[string]bool, len(hooks))
+	for _, h := range hooks {
+		if names[h.Name] {
+			return nil, fmt.Errorf("duplicate component %q", h.Name)
+		}
+		names[h.Name] = true
+	}
+	for _, h := range hooks {
+		for _, d := range h.DependsOn {
+			if !names[d] {
+				return nil, fmt.Errorf("component %q depends on unknown %q", h.Name, d)
+			}
+		}
+	}
+
+	done := make(map[string]bool, len(hooks))
+	sorted := make([]Hook, 0, len(hooks))
+	rest := hooks
+	for len(rest) > 0 {
+		i := 0
+		for ; i < len(rest); i++ {
+			if ready(rest[i], done) {
+				break
+			}
+		}
+		if i == len(rest) {
+			var cycle []string
+			for _, h := range rest {
+				cycle = append(cycle, h.Name)
+			}
+			return nil, fmt.Errorf("dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+		sorted = append(sorted, rest[i])
+		done[rest[i].Name] = true
+		rest = append(rest[:i:i], rest[i+1:]...)
+	}
+	return sorted, nil
+}
+
+func ready(h Hook, done map[string]bool) bool {
+	for _, d := range h.DependsOn {
+		if !done[d] {
+			return false
+		}
+	}
+	return true
+}
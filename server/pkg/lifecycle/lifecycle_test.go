@@ -0,0 +1,150 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder 记录组件启动和停止的顺序
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, startErr error, deps ...string) Hook {
+	return Hook{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(context.Context) error {
+			r.add("start " + name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, s)
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.calls, ", ")
+}
+
+func TestOrder(t *testing.T) {
+	rec := &recorder{}
+	app := New(time.Second)
+	app.Append(rec.hook("http", nil, "notes", "kafka"))
+	app.Append(rec.hook("notes", nil))
+	app.Append(rec.hook("kafka", nil))
+	app.Append(rec.hook("mysql", nil))
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := "start notes, start kafka, start http, start mysql, stop mysql, stop http, stop kafka, stop notes"
+	if got := rec.String(); got != want {
+		t.Errorf("calls:\n got %s\nwant %s", got, want)
+	}
+
+	for _, hooks := range [][]Hook{
+		{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+		{{Name: "a", DependsOn: []string{"missing"}}},
+		{{Name: "a"}, {Name: "a"}},
+	} {
+		app := New(time.Second)
+		for _, h := range hooks {
+			app.Append(h)
+		}
+		if err := app.Start(context.Background()); err == nil {
+			t.Errorf("%v: want error", hooks)
+		}
+	}
+}
+
+func TestStartRollback(t *testing.T) {
+	rec := &recorder{}
+	app := New(time.Second)
+	app.Append(rec.hook("notes", nil))
+	app.Append(rec.hook("kafka", errors.New("no brokers"), "notes"))
+	app.Append(rec.hook("http", nil, "kafka"))
+	err := app.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "start kafka: no brokers") {
+		t.Fatalf("err: %v", err)
+	}
+	if got, want := rec.String(), "start notes, start kafka, stop notes"; got != want {
+		t.Errorf("calls: got %s, want %s", got, want)
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	rec := &recorder{}
+	app := New(time.Second)
+	app.Append(rec.hook("notes", nil))
+	app.Append(Hook{Name: "stuck", Stop: func(context.Context) error { select {} }})
+	app.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := app.Stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop stuck") {
+		t.Fatalf("err: %v", err)
+	}
+	// 卡住的组件不影响之后的组件释放资源
+	if got := rec.String(); got != "start notes, stop notes" {
+		t.Errorf("calls: %s", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	// Go 注册的协程返回后 App 退出，错误作为 Run 的结果
+	app := New(time.Second)
+	stopped := make(chan struct{})
+	app.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	app.Go("export", func(context.Context) error { return errors.New("disk full") }, "worker")
+	if err := app.Run(context.Background()); err == nil || err.Error() != "export: disk full" {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("worker not stopped")
+	}
+
+	// ctx 取消后停止 HTTP 服务
+	app = New(time.Second)
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	app.Serve("http", srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("server not shut down: %v", err)
+	}
+
+	// 端口被占用时启动失败
+	app = New(time.Second)
+	app.Serve("http", &http.Server{Addr: "127.0.0.1:-1"})
+	if err := app.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "start http") {
+		t.Fatalf("err: %v", err)
+	}
+}
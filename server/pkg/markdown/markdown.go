@@ -0,0 +1,307 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"html/template"
+	"node/pkg/highlight"
+	"node/pkg/wikilink"
+	"strings"
+	"unicode"
+)
+
+// Heading 目录项
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// Document 渲染结果
+type Document struct {
+	HTML template.HTML
+	TOC  []Heading
+}
+
+// md 不开启 html.WithUnsafe：原始 HTML 会被忽略，javascript: 等危险链接会被过滤
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		// 优先级高于标准链接解析器（200），否则 [[ 会被当作普通链接的开头
+		parser.WithInlineParsers(util.Prioritized(&wikiParser{}, 199)),
+	),
+	goldmark.WithRendererOptions(
+		html.WithXHTML(),
+		html.WithHardWraps(), // 与之前前端 marked 的 breaks: true 保持一致
+		renderer.WithNodeRenderers(util.Prioritized(&nodeRenderer{}, 100)),
+	),
+)
+
+// LinkResolver 返回 [[ ]] 链接的地址，ok 为 false 表示目标笔记不存在；href 为空时按原文输出
+type LinkResolver func(l wikilink.Link) (href string, ok bool)
+
+// Option 渲染选项
+type Option func(ctx parser.Context)
+
+var resolverKey = parser.NewContextKey()
+
+// WithLinkResolver 启用 [[ ]] 笔记链接
+func WithLinkResolver(fn LinkResolver) Option {
+	return func(ctx parser.Context) {
+		ctx.Set(resolverKey, fn)
+	}
+}
+
+// AssetResolver 返回图片或附件链接改写后的地址，ok 为 false 时保留原地址；image 区分 ![](dest) 和 [](dest)
+type AssetResolver func(dest string, image bool) (href string, ok bool)
+
+var assetKey = parser.NewContextKey()
+
+// WithAssetResolver 改写图片和附件的相对地址，使其指向笔记旁边的文件
+func WithAssetResolver(fn AssetResolver) Option {
+	return func(ctx parser.Context) {
+		ctx.Set(assetKey, fn)
+	}
+}
+
+// Render 把 Markdown 渲染为安全的 HTML，并提取标题生成目录
+func Render(src []byte, opts ...Option) (*Document, error) {
+	ctx := parser.NewContext(parser.WithIDs(newIDs()))
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	asset, _ := ctx.Get(assetKey).(AssetResolver)
+	var toc []Heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Image:
+			if asset != nil {
+				t.Destination = rewrite(asset, t.Destination, true)
+			}
+		case *ast.Link:
+			if asset != nil {
+				t.Destination = rewrite(asset, t.Destination, false)
+			}
+		}
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		id, _ := h.AttributeString("id")
+		idb, _ := id.([]byte)
+		toc = append(toc, Heading{Level: h.Level, ID: string(idb), Text: plainText(h, src)})
+		return ast.WalkSkipChildren, nil
+	})
+
+	buf := &bytes.Buffer{}
+	if err := md.Renderer().Render(buf, src, doc); err != nil {
+		return nil, fmt.Errorf("render markdown: %w", err)
+	}
+	return &Document{HTML: template.HTML(buf.String()), TOC: toc}, nil
+}
+
+func rewrite(fn AssetResolver, dest []byte, image bool) []byte {
+	if href, ok := fn(string(dest), image); ok {
+		return []byte(href)
+	}
+	return dest
+}
+
+func plainText(n ast.Node, src []byte) string {
+	sb := &strings.Builder{}
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(src))
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
+
+// ids 标题锚点生成，保留中文等非 ASCII 字符（goldmark 默认实现会丢弃）
+type ids struct {
+	values map[string]bool
+}
+
+func newIDs() *ids {
+	return &ids{values: make(map[string]bool)}
+}
+
+// Slug 标题文字转换为锚点，[[note#标题]] 链接用它定位到对应标题
+func Slug(value string) string {
+	sb := &strings.Builder{}
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			sb.WriteByte('-')
+		}
+	}
+	return sb.String()
+}
+
+func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
+	id := Slug(string(value))
+	if id == "" {
+		id = "heading"
+	}
+	if !s.values[id] {
+		s.values[id] = true
+		return []byte(id)
+	}
+	for i := 1; ; i++ {
+		next := fmt.Sprintf("%s-%d", id, i)
+		if !s.values[next] {
+			s.values[next] = true
+			return []byte(next)
+		}
+	}
+}
+
+func (s *ids) Put(value []byte) {
+	s.values[string(value)] = true
+}
+
+// nodeRenderer 在标题前输出可点击的锚点，并高亮代码块
+type nodeRenderer struct{}
+
+func (r *nodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, r.renderHeading)
+	reg.Register(ast.KindFencedCodeBlock, r.renderCode)
+	reg.Register(kindWikiLink, r.renderWikiLink)
+}
+
+func (r *nodeRenderer) renderWikiLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*wikiLink)
+	w.WriteString(`<a class="wikilink`)
+	if n.Broken {
+		w.WriteString(` broken" title="笔记不存在`)
+	}
+	w.WriteString(`" href="`)
+	w.Write(util.EscapeHTML([]byte(n.Href)))
+	w.WriteString(`">`)
+	w.Write(util.EscapeHTML([]byte(n.Link.Text())))
+	w.WriteString("</a>")
+	return ast.WalkSkipChildren, nil
+}
+
+// renderCode 代码块在服务端高亮，不支持的语言原样转义输出
+func (r *nodeRenderer) renderCode(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	lang := string(n.Language(src))
+	code := &strings.Builder{}
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(src))
+	}
+
+	w.WriteString("<pre><code")
+	if lang != "" {
+		w.WriteString(` class="language-`)
+		w.Write(util.EscapeHTML([]byte(lang)))
+		w.WriteString(`"`)
+	}
+	w.WriteString(">")
+	w.WriteString(string(highlight.Inline(lang, code.String())))
+	w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *nodeRenderer) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	if !entering {
+		fmt.Fprintf(w, "</h%d>\n", n.Level)
+		return ast.WalkContinue, nil
+	}
+	fmt.Fprintf(w, "<h%d", n.Level)
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, node, html.HeadingAttributeFilter)
+	}
+	w.WriteByte('>')
+	if id, ok := n.AttributeString("id"); ok {
+		if b, ok := id.([]byte); ok {
+			w.WriteString(`<a class="anchor" href="#`)
+			w.Write(util.EscapeHTML(b))
+			w.WriteString(`">#</a>`)
+		}
+	}
+	return ast.WalkContinue, nil
+}
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLink [[target#anchor|label]] 笔记链接
+type wikiLink struct {
+	ast.BaseInline
+	Link   wikilink.Link
+	Href   string
+	Broken bool
+}
+
+func (n *wikiLink) Kind() ast.NodeKind {
+	return kindWikiLink
+}
+
+func (n *wikiLink) Dump(src []byte, level int) {
+	ast.DumpHelper(n, src, level, map[string]string{"Target": n.Link.Target, "Href": n.Href}, nil)
+}
+
+// wikiParser 未设置 LinkResolver 时不做解析，[[ ]] 按普通文本输出
+type wikiParser struct{}
+
+func (p *wikiParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *wikiParser) Parse(_ ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	resolve, _ := pc.Get(resolverKey).(LinkResolver)
+	if resolve == nil {
+		return nil
+	}
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	l, ok := wikilink.Parse(string(line[2 : 2+end]))
+	if !ok {
+		return nil
+	}
+	href, exists := resolve(l)
+	if href == "" {
+		return nil
+	}
+	block.Advance(end + 4)
+	return &wikiLink{Link: l, Href: href, Broken: !exists}
+}
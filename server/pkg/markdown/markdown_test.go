@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"node/pkg/wikilink"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	src := "# 面试 八股文\n\n" +
+		"## Context 用法\n\n" +
+		"| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+		"- [x] done\n- [ ] todo\n\n" +
+		"```go\nfmt.Println(`${x}`)\n```\n\n" +
+		"<script>alert(1)</script>\n\n" +
+		"[x](javascript:alert(1))\n"
+
+	doc, err := Render([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(doc.HTML)
+
+	for _, want := range []string{
+		`<h1 id="面试-八股文"><a class="anchor" href="#面试-八股文">#</a>面试 八股文</h1>`,
+		`<table>`,
+		`<input checked="" disabled="" type="checkbox" />`,
+		`<code class="language-go">fmt.Println(`,
+		"<span class=\"str\">`${x}`</span>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, bad := range []string{"<script>", "javascript:"} {
+		if strings.Contains(out, bad) {
+			t.Errorf("unsafe %q rendered:\n%s", bad, out)
+		}
+	}
+
+	if len(doc.TOC) != 2 || doc.TOC[1].ID != "context-用法" || doc.TOC[1].Text != "Context 用法" || doc.TOC[1].Level != 2 {
+		t.Errorf("unexpected toc: %+v", doc.TOC)
+	}
+}
+
+func TestWikiLink(t *testing.T) {
+	src := []byte("见 [[Golang/context.go|context]]、[[missing]] 和 `[[code]]`\n")
+	resolve := func(l wikilink.Link) (string, bool) {
+		if l.Target == "Golang/context.go" {
+			return "/view/Golang%2Fcontext.go", true
+		}
+		return "/edit/" + l.Target, false
+	}
+	doc, err := Render(src, WithLinkResolver(resolve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p>见 <a class="wikilink" href="/view/Golang%2Fcontext.go">context</a>、` +
+		`<a class="wikilink broken" title="笔记不存在" href="/edit/missing">missing</a> 和 <code>[[code]]</code></p>`
+	if got := strings.TrimSpace(string(doc.HTML)); got != want {
+		t.Fatalf("got %s", got)
+	}
+
+	// 未设置 LinkResolver 时按原文输出
+	doc, _ = Render(src)
+	if !strings.Contains(string(doc.HTML), "[[missing]]") {
+		t.Fatalf("got %s", doc.HTML)
+	}
+}
+
+func TestAssetResolver(t *testing.T) {
+	src := []byte("![图](img/a.png) [附件](a.pdf) [笔记](b.md)\n")
+	asset := func(dest string, image bool) (string, bool) {
+		if !image && !strings.HasSuffix(dest, ".pdf") {
+			return "", false
+		}
+		return "/raw/Golang/" + dest, true
+	}
+	doc, err := Render(src, WithAssetResolver(asset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p><img src="/raw/Golang/img/a.png" alt="图" /> <a href="/raw/Golang/a.pdf">附件</a> <a href="b.md">笔记</a></p>`
+	if got := strings.TrimSpace(string(doc.HTML)); got != want {
+		t.Fatalf("got %s", got)
+	}
+}
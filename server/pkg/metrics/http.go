@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = NewCounter("http_requests_total", "HTTP requests by method, route and status code.", "method", "route", "code")
+	httpDuration = NewHistogram("http_request_duration_seconds", "HTTP request latency by method and route.", DefBuckets, "method", "route")
+	httpInFlight = NewGauge("http_requests_in_flight", "HTTP requests currently being served.")
+)
+
+// Middleware 统计请求数、耗时和进行中的请求。route 取 ServeMux 匹配到的模式（如 /view/{path...}），
+// 不按实际路径区分，避免时间序列随笔记数量增长；需要直接包在 ServeMux 外层，ServeMux 才会把模式写回同一个请求
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		inFlight := httpInFlight.With()
+		inFlight.Inc()
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			inFlight.Dec()
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			if sw.code == 0 {
+				sw.code = http.StatusOK
+			}
+			method := method(r.Method)
+			httpRequests.With(method, route, strconv.Itoa(sw.code)).Inc()
+			httpDuration.With(method, route).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// method 非标准的方法归为 OTHER，防止任意字符串成为标签值
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "OTHER"
+}
+
+// statusWriter 记录响应状态码
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 供 http.ResponseController 找到底层的 Flush 和 SetWriteDeadline
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,294 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认的直方图分桶，单位为秒，覆盖 5ms 到 10s
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default 各个包注册指标的默认注册表，/metrics 输出它
+var Default = NewRegistry()
+
+// Registry 一组指标，按 Prometheus 文本格式输出
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family 同名指标的全部时间序列，每组标签值一条
+type family struct {
+	name    string
+	help    string
+	typ     string // counter | gauge | histogram
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  atomicFloat // counter 和 gauge 的值，histogram 的和
+	counts []atomic.Uint64
+	count  atomic.Uint64
+}
+
+// register 同名指标只注册一次，重复注册时返回已有的；类型或标签不同属于程序错误
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: %s registered as %s%v, got %s%v", name, f.typ, f.labels, typ, labels))
+		}
+		return f
+	}
+	if typ == "histogram" && !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s buckets not sorted", name))
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	// 没有标签的指标在抓取时就有值，不必等到第一次更新
+	if len(labels) == 0 {
+		f.with(nil)
+	}
+	r.families[name] = f
+	return f
+}
+
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s = &series{values: append([]string(nil), values...)}
+	if f.typ == "histogram" {
+		s.counts = make([]atomic.Uint64, len(f.buckets))
+	}
+	f.series[key] = s
+	return s
+}
+
+// CounterVec 只增不减的计数，如请求数、错误数
+type CounterVec struct{ f *family }
+
+// Counter 一组标签值对应的计数
+type Counter struct{ s *series }
+
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", nil, labels)}
+}
+
+// With 按注册时的标签顺序传入标签值
+func (v *CounterVec) With(values ...string) Counter { return Counter{v.f.with(values)} }
+func (c Counter) Inc()                              { c.s.value.Add(1) }
+func (c Counter) Add(n float64)                     { c.s.value.Add(n) }
+
+// GaugeVec 可增可减的当前值，如进行中的请求数、队列长度
+type GaugeVec struct{ f *family }
+
+type Gauge struct{ s *series }
+
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, "gauge", nil, labels)}
+}
+
+func (v *GaugeVec) With(values ...string) Gauge { return Gauge{v.f.with(values)} }
+func (g Gauge) Set(n float64)                   { g.s.value.Store(n) }
+func (g Gauge) Add(n float64)                   { g.s.value.Add(n) }
+func (g Gauge) Inc()                            { g.s.value.Add(1) }
+func (g Gauge) Dec()                            { g.s.value.Add(-1) }
+
+// HistogramVec 按分桶统计的分布，如请求耗时
+type HistogramVec struct{ f *family }
+
+type Histogram struct {
+	s       *series
+	buckets []float64
+}
+
+// Histogram buckets 为各桶的上界，从小到大排列，+Inf 桶自动添加
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{r.register(name, help, "histogram", buckets, labels)}
+}
+
+func (v *HistogramVec) With(values ...string) Histogram {
+	return Histogram{v.f.with(values), v.f.buckets}
+}
+
+func (h Histogram) Observe(n float64) {
+	if i := sort.SearchFloat64s(h.buckets, n); i < len(h.buckets) {
+		h.s.counts[i].Add(1)
+	}
+	h.s.count.Add(1)
+	h.s.value.Add(n)
+}
+
+// NewCounter 在 Default 中注册计数
+func NewCounter(name, help string, labels ...string) *CounterVec {
+	return Default.Counter(name, help, labels...)
+}
+
+// NewGauge 在 Default 中注册当前值
+func NewGauge(name, help string, labels ...string) *GaugeVec {
+	return Default.Gauge(name, help, labels...)
+}
+
+// NewHistogram 在 Default 中注册直方图
+func NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.Histogram(name, help, buckets, labels...)
+}
+
+// WriteTo 按 Prometheus 文本格式输出，指标按名称、时间序列按标签值排序
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+func (f *family) write(w *countWriter) {
+	f.mu.RLock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return strings.Join(all[i].values, "\xff") < strings.Join(all[j].values, "\xff")
+	})
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for _, s := range all {
+		if f.typ != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labelSet(f.labels, s.values, "", ""), formatFloat(s.value.Load()))
+			continue
+		}
+		var cum uint64
+		for i, le := range f.buckets {
+			cum += s.counts[i].Load()
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelSet(f.labels, s.values, "le", formatFloat(le)), cum)
+		}
+		count := s.count.Load()
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelSet(f.labels, s.values, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labelSet(f.labels, s.values, "", ""), formatFloat(s.value.Load()))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labelSet(f.labels, s.values, "", ""), count)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// labelSet 输出 {a="1",b="2"}，extra 不为空时追加一个标签（直方图的 le）
+func labelSet(names, values []string, extra, extraValue string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	b := &strings.Builder{}
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, `%s="%s"`, name, valueEscaper.Replace(values[i]))
+	}
+	if extra != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, `%s="%s"`, extra, extraValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ServeHTTP 输出全部指标，供 Prometheus 抓取
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	r.WriteTo(w)
+}
+
+// atomicFloat 用 CAS 实现的 float64 原子加法
+type atomicFloat struct{ bits atomic.Uint64 }
+
+func (a *atomicFloat) Add(n float64) {
+	for {
+		old := a.bits.Load()
+		if a.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+n)) {
+			return
+		}
+	}
+}
+
+func (a *atomicFloat) Store(n float64) { a.bits.Store(math.Float64bits(n)) }
+func (a *atomicFloat) Load() float64   { return math.Float64frombits(a.bits.Load()) }
+
+// countWriter 记录写入的字节数和第一个错误
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
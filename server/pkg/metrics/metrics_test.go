@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("kafka_publish_errors_total", "Publish errors.", "topic")
+	c.With("note.events").Inc()
+	c.With("note.events").Add(2)
+	c.With(`a"b\c`).Inc()
+	g := r.Gauge("worker_pool_queue_depth", "Queued tasks.\nPer pool.")
+	g.With().Set(3)
+	g.With().Dec()
+	h := r.Histogram("gorm_query_duration_seconds", "Query latency.", []float64{0.1, 1}, "operation")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.With("query").Observe(v)
+	}
+	// 重复注册返回同一个指标
+	r.Counter("kafka_publish_errors_total", "Publish errors.", "topic").With("note.events").Inc()
+
+	buf := &strings.Builder{}
+	if _, err := r.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP gorm_query_duration_seconds Query latency.
+# TYPE gorm_query_duration_seconds histogram
+gorm_query_duration_seconds_bucket{operation="query",le="0.1"} 2
+gorm_query_duration_seconds_bucket{operation="query",le="1"} 3
+gorm_query_duration_seconds_bucket{operation="query",le="+Inf"} 4
+gorm_query_duration_seconds_sum{operation="query"} 3.65
+gorm_query_duration_seconds_count{operation="query"} 4
+# HELP kafka_publish_errors_total Publish errors.
+# TYPE kafka_publish_errors_total counter
+kafka_publish_errors_total{topic="a\"b\\c"} 1
+kafka_publish_errors_total{topic="note.events"} 4
+# HELP worker_pool_queue_depth Queued tasks.\nPer pool.
+# TYPE worker_pool_queue_depth gauge
+worker_pool_queue_depth 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a different type should panic")
+		}
+	}()
+	r.Gauge("kafka_publish_errors_total", "", "topic")
+}
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /view/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		// 长连接的 Flush 要能穿过包装
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Error(err)
+		}
+	})
+	h := Middleware(mux)
+	for _, p := range []string{"/view/a.md", "/view/b.md", "/missing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/view/a.md", nil))
+
+	buf := &strings.Builder{}
+	Default.WriteTo(buf)
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="GET /view/{path...}",code="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",code="404"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",code="405"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="GET /view/{path...}"} 2`,
+		"http_requests_in_flight 0\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s in:\n%s", want, buf)
+		}
+	}
+}
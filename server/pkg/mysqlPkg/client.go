@@ -2,6 +2,7 @@ package mysqlPkg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -47,7 +48,38 @@ func (c *Client) load() *Client {
 	return c
 }
 
+// WithContext 当前的连接池，Reload 之后返回新的连接池
+func (c *Client) WithContext(ctx context.Context) *gorm.DB {
+	return c.value.Load().(*gorm.DB).WithContext(ctx)
+}
+
+// Ping 检查当前连接池能否连上数据库
+func (c *Client) Ping(ctx context.Context) error {
+	mydb, err := c.WithContext(ctx).DB()
+	if err != nil {
+		return err
+	}
+	return mydb.PingContext(ctx)
+}
+
+// Close 关闭当前的连接池
+func (c *Client) Close() error {
+	db, ok := c.value.Load().(*gorm.DB)
+	if !ok {
+		return nil
+	}
+	mydb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return mydb.Close()
+}
+
 func (c *Client) Reload(config *Config) (err error) {
+	if config == nil {
+		return errors.New("mysql: config is nil")
+	}
+
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -119,6 +151,12 @@ func (c *Client) Reload(config *Config) (err error) {
 		mydb.SetConnMaxLifetime(time.Duration(config.MaxLifetime) * time.Second)
 	}
 
+	//registerTraceCallbacks(c)
+	if err := registerMetricsCallbacks(db, mycfg.DBName); err != nil {
+		mydb.Close()
+		return err
+	}
+
 	if oldDB, ok := c.value.Load().(*gorm.DB); ok {
 		oldMycfg, _ := c.config.NewMycfg()
 		defer func(old *gorm.DB, dsn string) {
@@ -130,11 +168,9 @@ func (c *Client) Reload(config *Config) (err error) {
 	}
 
 	c.value.Store(db)
+	c.DB = db
 	c.config = config
 	c.config.mycfg = mycfg
 
-	//registerTraceCallbacks(c)
-	//registerMetricsCallbacks(c)
-
 	return nil
 }
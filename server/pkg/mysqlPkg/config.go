@@ -1,6 +1,7 @@
 package mysqlPkg
 
 import (
+	"errors"
 	"github.com/go-sql-driver/mysql"
 	"strings"
 	"time"
@@ -17,6 +18,7 @@ type Config struct {
 	MaxLifetime          int           `yaml:"max_life_time" toml:"max_life_time"`                     // 空闲连接最大存活时间，默认 600s
 	TraceIncludeNotFound bool          `yaml:"trace_include_not_found" toml:"trace_include_not_found"` // 是否将NotFound error作为错误记录在trace中，默认为否
 	DebugSQL             bool          `yaml:"debug_sql" toml:"debug_sql"`
+	ParseTime            bool          `yaml:"parse_time" toml:"parse_time"` // 为 true 时在 DSN 中加上 parseTime=true，DATETIME 扫描为 time.Time
 	//internal
 	mycfg *mysql.Config `yaml:"mycfg" toml:"mycfg"`
 }
@@ -70,12 +72,24 @@ func (c *Config) NewMycfg() (dsn *mysql.Config, err error) {
 		if dsn.WriteTimeout <= 0 {
 			dsn.WriteTimeout = c.WriteTimeout * time.Millisecond
 		}
+		if c.ParseTime {
+			dsn.ParseTime = true
+		}
 
 		c.DSN = dsn.FormatDSN()
 		return dsn, nil
 	}
 
-	return
+	return nil, errors.New("mysql: dsn is empty")
+}
+
+// ParsesTime parse_time 为 true，或 DSN 中已经带有 parseTime=true
+func (c *Config) ParsesTime() bool {
+	if c.ParseTime {
+		return true
+	}
+	dsn, err := mysql.ParseDSN(c.DSN)
+	return err == nil && dsn.ParseTime
 }
 
 func (c *Config) NewWithDB(dbName string) (*Config, error) {
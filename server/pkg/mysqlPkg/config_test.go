@@ -0,0 +1,33 @@
+package mysqlPkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMycfg(t *testing.T) {
+	c := &Config{DSN: "root@tcp(127.0.0.1:3306)/notes", DialTimeout: 500, ReadTimeout: 2000}
+	dsn, err := c.NewMycfg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 配置中的超时按毫秒计
+	if dsn.Timeout != 500*time.Millisecond || dsn.ReadTimeout != 2*time.Second {
+		t.Errorf("timeouts: %v %v", dsn.Timeout, dsn.ReadTimeout)
+	}
+	if dsn.ParseTime || c.ParsesTime() {
+		t.Error("parseTime enabled without parse_time")
+	}
+
+	c = &Config{DSN: "root@tcp(127.0.0.1:3306)/notes", ParseTime: true}
+	if dsn, err = c.NewMycfg(); err != nil || !dsn.ParseTime {
+		t.Errorf("parse_time: %v %v", dsn, err)
+	}
+	if c := (&Config{DSN: "root@tcp(127.0.0.1:3306)/notes?parseTime=true"}); !c.ParsesTime() {
+		t.Error("parseTime in dsn not detected")
+	}
+
+	if _, err := (&Config{}).NewMycfg(); err == nil {
+		t.Error("empty dsn accepted")
+	}
+}
@@ -1,6 +1,7 @@
 package mysqlPkg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"golang.org/x/sync/errgroup"
@@ -118,6 +119,34 @@ func (mgr *Manager) Del(name string) {
 	mgr.configs.Delete(name)
 }
 
+// Ping 检查名为 name 的数据库是否可用，尚未连接时先建立连接
+func (mgr *Manager) Ping(ctx context.Context, name string) error {
+	client, err := mgr.GetClient(name)
+	if err != nil {
+		return err
+	}
+	return client.Ping(ctx)
+}
+
+// Close 关闭已创建的全部连接池，之后的 GetClient 会重新连接
+func (mgr *Manager) Close() error {
+	if mgr == nil {
+		return nil
+	}
+
+	var errs []error
+	mgr.clients.Range(func(name, iface interface{}) bool {
+		if client, ok := iface.(*Client); ok {
+			if err := client.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close %v: %w", name, err))
+			}
+		}
+		mgr.clients.Delete(name)
+		return true
+	})
+	return errors.Join(errs...)
+}
+
 func (mgr *Manager) Load(configs ManagerConfig) {
 	if mgr == nil {
 		return
@@ -0,0 +1,50 @@
+package mysqlPkg
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"node/pkg/metrics"
+	"time"
+)
+
+var (
+	queryDuration = metrics.NewHistogram("gorm_query_duration_seconds", "GORM statement latency by database and operation.", metrics.DefBuckets, "db", "operation")
+	queryErrors   = metrics.NewCounter("gorm_query_errors_total", "GORM statements that returned an error other than record not found.", "db", "operation")
+)
+
+const metricsStartKey = "metrics:start"
+
+// registerMetricsCallbacks 在 gorm 的各类语句前后记录耗时和错误，db 标签为数据库名
+func registerMetricsCallbacks(gdb *gorm.DB, name string) error {
+	before := func(db *gorm.DB) {
+		db.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(op string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			v, ok := db.InstanceGet(metricsStartKey)
+			if !ok {
+				return
+			}
+			queryDuration.With(name, op).Observe(time.Since(v.(time.Time)).Seconds())
+			if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+				queryErrors.With(name, op).Inc()
+			}
+		}
+	}
+
+	cb := gdb.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	)
+}
@@ -0,0 +1,35 @@
+package mysqlPkg
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"node/pkg/metrics"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCallbacks(t *testing.T) {
+	// DryRun 只生成 SQL 不连接数据库，回调照常执行
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "root@tcp(127.0.0.1:1)/notes", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerMetricsCallbacks(db, "notes"); err != nil {
+		t.Fatal(err)
+	}
+	type row struct{ ID int }
+	var rows []row
+	db.Table("t").Find(&rows)
+	db.Table("t").Create(&row{ID: 1})
+
+	buf := &strings.Builder{}
+	metrics.Default.WriteTo(buf)
+	for _, want := range []string{
+		`gorm_query_duration_seconds_count{db="notes",operation="query"} 1`,
+		`gorm_query_duration_seconds_count{db="notes",operation="create"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
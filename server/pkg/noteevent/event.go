@@ -0,0 +1,141 @@
+// Package noteevent 对比笔记内容的前后版本，生成 note.created / note.updated / note.deleted 事件
+package noteevent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"node/pkg/diff"
+	"sync"
+	"time"
+)
+
+const (
+	Created = "note.created"
+	Updated = "note.updated"
+	Deleted = "note.deleted"
+)
+
+// claimTTL 编辑接口登记的作者在多长时间内有效，超时后认为变化来自外部
+const claimTTL = time.Minute
+
+// Event 笔记变化事件，序列化为 JSON 发送到消息队列
+type Event struct {
+	Type     string    `json:"type"`
+	Path     string    `json:"path"`
+	Hash     string    `json:"hash,omitempty"`      // 变化后内容的 sha256，删除时为空
+	PrevHash string    `json:"prev_hash,omitempty"` // 变化前内容的 sha256，新建时为空
+	Author   string    `json:"author,omitempty"`    // 通过编辑接口修改时的用户，外部修改为空
+	Time     time.Time `json:"timestamp"`
+	Size     int       `json:"size"`      // 变化后的字节数
+	Added    int       `json:"added"`     // 新增的行数
+	Removed  int       `json:"removed"`   // 删除的行数
+	DiffSize int       `json:"diff_size"` // Added + Removed
+}
+
+type note struct {
+	hash    string
+	content string
+}
+
+type claim struct {
+	author string
+	at     time.Time
+}
+
+// Tracker 记录每个笔记最近一次的内容。Ready 之前只建立基线，不产生事件，
+// 避免启动时把已有笔记全部当作新建
+type Tracker struct {
+	mu     sync.Mutex
+	ready  bool
+	notes  map[string]note
+	claims map[string]claim
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{notes: make(map[string]note), claims: make(map[string]claim)}
+}
+
+// Ready 基线建立完成，之后的变化产生事件
+func (t *Tracker) Ready() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ready = true
+}
+
+// Claim 编辑接口写入前登记作者，下一次检测到 p 的变化时使用
+func (t *Tracker) Claim(p, author string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for k, c := range t.claims {
+		if now.Sub(c.at) > claimTTL {
+			delete(t.claims, k)
+		}
+	}
+	t.claims[p] = claim{author: author, at: now}
+}
+
+// Observe 记录 p 的当前内容，内容变化时返回事件；modTime 作为事件时间
+func (t *Tracker) Observe(p string, b []byte, modTime time.Time) *Event {
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	old, existed := t.notes[p]
+	if existed && old.hash == hash {
+		return nil
+	}
+	t.notes[p] = note{hash: hash, content: string(b)}
+	if !t.ready {
+		return nil
+	}
+	e := &Event{Type: Created, Path: p, Hash: hash, Author: t.author(p), Time: modTime, Size: len(b)}
+	if existed {
+		e.Type, e.PrevHash = Updated, old.hash
+	}
+	e.Added, e.Removed = diff.Stat(old.content, string(b))
+	e.DiffSize = e.Added + e.Removed
+	return e
+}
+
+// Retain 删除 keep 返回 false 的笔记，返回对应的删除事件
+func (t *Tracker) Retain(keep func(p string) bool) []*Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var events []*Event
+	now := time.Now()
+	for p, old := range t.notes {
+		if keep(p) {
+			continue
+		}
+		delete(t.notes, p)
+		if !t.ready {
+			continue
+		}
+		_, removed := diff.Stat(old.content, "")
+		events = append(events, &Event{
+			Type:     Deleted,
+			Path:     p,
+			PrevHash: old.hash,
+			Author:   t.author(p),
+			Time:     now,
+			Removed:  removed,
+			DiffSize: removed,
+		})
+	}
+	return events
+}
+
+// author 取出并清除 p 登记的作者，调用方持有锁
+func (t *Tracker) author(p string) string {
+	c, ok := t.claims[p]
+	if !ok {
+		return ""
+	}
+	delete(t.claims, p)
+	if time.Since(c.at) > claimTTL {
+		return ""
+	}
+	return c.author
+}
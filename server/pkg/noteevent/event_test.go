@@ -0,0 +1,34 @@
+package noteevent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+	now := time.Now()
+	if e := tr.Observe("a.md", []byte("a\n"), now); e != nil {
+		t.Fatalf("event before ready: %+v", e)
+	}
+	tr.Ready()
+
+	if e := tr.Observe("a.md", []byte("a\n"), now); e != nil {
+		t.Fatalf("unchanged content: %+v", e)
+	}
+	tr.Claim("a.md", "alice")
+	e := tr.Observe("a.md", []byte("a\nb\nc\n"), now)
+	if e == nil || e.Type != Updated || e.Author != "alice" || e.PrevHash == "" || e.Hash == e.PrevHash || e.Added != 2 || e.DiffSize != 2 || e.Size != 6 {
+		t.Fatalf("update: %+v", e)
+	}
+
+	e = tr.Observe("b.md", []byte("x\n"), now)
+	if e == nil || e.Type != Created || e.Author != "" || e.PrevHash != "" || e.Added != 1 {
+		t.Fatalf("create: %+v", e)
+	}
+
+	events := tr.Retain(func(p string) bool { return p == "b.md" })
+	if len(events) != 1 || events[0].Type != Deleted || events[0].Path != "a.md" || events[0].Removed != 3 || events[0].Hash != "" {
+		t.Fatalf("delete: %+v", events)
+	}
+}
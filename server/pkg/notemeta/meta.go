@@ -0,0 +1,213 @@
+package notemeta
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxSummary = 200
+
+// Meta 笔记元信息，来自 Markdown 的 YAML front matter 或 Go 文件 package 之前的注释
+type Meta struct {
+	Title   string    `json:"title,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Date    time.Time `json:"date,omitzero"`
+	Summary string    `json:"summary,omitempty"`
+}
+
+// Parse 解析元信息，返回去掉 front matter 之后的正文；不支持的文件类型原样返回
+func Parse(name string, src []byte) (Meta, []byte) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return parseMarkdown(src)
+	case ".go":
+		return parseGo(src), src
+	}
+	return Meta{}, src
+}
+
+// parseMarkdown 支持 --- 包裹的简单 YAML：key: value、tags: [a, b]、tags: a, b 以及 - 列表
+func parseMarkdown(src []byte) (Meta, []byte) {
+	var m Meta
+	body := src
+	text := string(bytes.TrimPrefix(src, []byte("\xef\xbb\xbf")))
+	if strings.HasPrefix(text, "---\n") || strings.HasPrefix(text, "---\r\n") {
+		rest := text[strings.IndexByte(text, '\n')+1:]
+		end := -1
+		offset := 0
+		for _, line := range strings.SplitAfter(rest, "\n") {
+			if t := strings.TrimSpace(line); t == "---" || t == "..." {
+				end = offset
+				offset += len(line)
+				break
+			}
+			offset += len(line)
+		}
+		if end >= 0 {
+			parseYAML(&m, rest[:end])
+			body = []byte(rest[offset:])
+		}
+	}
+
+	if m.Title == "" {
+		sc := bufio.NewScanner(bytes.NewReader(body))
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); strings.HasPrefix(line, "# ") {
+				m.Title = strings.TrimSpace(line[2:])
+				break
+			}
+		}
+	}
+	return m, body
+}
+
+func parseYAML(m *Meta, front string) {
+	var listKey string
+	for _, line := range strings.Split(front, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") && listKey != "" {
+			m.set(listKey, trimmed[2:], true)
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		listKey = ""
+		if value == "" {
+			listKey = key
+			continue
+		}
+		m.set(key, value, false)
+	}
+}
+
+// set 设置字段，item 表示 YAML 列表中的一项
+func (m *Meta) set(key, value string, item bool) {
+	switch key {
+	case "title":
+		m.Title = unquote(value)
+	case "summary", "description":
+		m.Summary = unquote(value)
+	case "date":
+		m.Date = parseDate(unquote(value))
+	case "tags", "tag", "keywords":
+		if item {
+			m.addTag(value)
+			return
+		}
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		for _, t := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' }) {
+			m.addTag(t)
+		}
+	}
+}
+
+func (m *Meta) addTag(tag string) {
+	tag = strings.TrimPrefix(unquote(tag), "#")
+	if tag == "" {
+		return
+	}
+	for _, t := range m.Tags {
+		if t == tag {
+			return
+		}
+	}
+	m.Tags = append(m.Tags, tag)
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		s = s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
+
+func parseDate(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseGo 读取 package 之前的 // 或 /* */ 注释：title:/tags:/date:/summary: 行按键解析，
+// 否则第一行作为标题，其余作为摘要
+func parseGo(src []byte) Meta {
+	var lines []string
+	inBlock := false
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case inBlock:
+			if i := strings.Index(line, "*/"); i >= 0 {
+				line, inBlock = line[:i], false
+			}
+			lines = append(lines, strings.TrimPrefix(line, "*"))
+		case strings.HasPrefix(line, "//"):
+			lines = append(lines, strings.TrimPrefix(line, "//"))
+		case strings.HasPrefix(line, "/*"):
+			line = line[2:]
+			if i := strings.Index(line, "*/"); i >= 0 {
+				line = line[:i]
+			} else {
+				inBlock = true
+			}
+			lines = append(lines, line)
+		case line == "":
+			continue
+		default:
+			// 遇到 package 或其他代码即结束
+			return goMeta(lines)
+		}
+	}
+	return goMeta(lines)
+}
+
+func goMeta(lines []string) Meta {
+	var (
+		m    Meta
+		rest []string
+	)
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "go:") || strings.HasPrefix(line, "+build") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			switch k := strings.ToLower(strings.TrimSpace(key)); k {
+			case "title", "tags", "tag", "date", "summary":
+				m.set(k, value, false)
+				continue
+			}
+		}
+		rest = append(rest, line)
+	}
+	if m.Title == "" && len(rest) > 0 {
+		m.Title, rest = rest[0], rest[1:]
+	}
+	if m.Summary == "" {
+		m.Summary = truncate(strings.Join(rest, " "), maxSummary)
+	}
+	return m
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
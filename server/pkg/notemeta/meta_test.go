@@ -0,0 +1,52 @@
+package notemeta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	src := "---\ntitle: \"Kafka 笔记\"\ntags: [kafka, 消息队列]\ndate: 2025-01-02\nsummary: broker/topic/partition\n---\n# 标题\n正文\n"
+	m, body := Parse("Golang/pkg/readme.md", []byte(src))
+	if m.Title != "Kafka 笔记" || strings.Join(m.Tags, ",") != "kafka,消息队列" || m.Summary != "broker/topic/partition" {
+		t.Fatalf("unexpected meta: %+v", m)
+	}
+	if m.Date.Format("2006-01-02") != "2025-01-02" {
+		t.Fatalf("date: %v", m.Date)
+	}
+	if string(body) != "# 标题\n正文\n" {
+		t.Fatalf("body: %q", body)
+	}
+
+	m, _ = Parse("a.md", []byte("---\ntags:\n  - go\n  - 'context'\n---\n\n# 用 context 控制超时\n"))
+	if m.Title != "用 context 控制超时" || strings.Join(m.Tags, ",") != "go,context" {
+		t.Fatalf("list tags / heading title: %+v", m)
+	}
+
+	// 没有结束标记时不当作 front matter
+	m, body = Parse("a.md", []byte("---\ntitle: x\n"))
+	if m.Title != "" || len(body) == 0 {
+		t.Fatalf("unterminated front matter: %+v %q", m, body)
+	}
+}
+
+func TestGo(t *testing.T) {
+	src := "//go:build ignore\n\n// LRU 缓存实现\n// tags: 算法, 链表\n// 双向链表 + map\n// O(1) 读写\npackage listnode\n\n// not meta\n"
+	m, body := Parse("算法/listnode/lru.go", []byte(src))
+	if m.Title != "LRU 缓存实现" || strings.Join(m.Tags, ",") != "算法,链表" || m.Summary != "双向链表 + map O(1) 读写" {
+		t.Fatalf("unexpected meta: %+v", m)
+	}
+	if string(body) != src {
+		t.Fatal("go body should be unchanged")
+	}
+
+	m, _ = Parse("x.go", []byte("/*\n Title: select 用法\n*/\npackage main\n"))
+	if m.Title != "select 用法" {
+		t.Fatalf("block comment: %+v", m)
+	}
+
+	m, _ = Parse("x.go", []byte("package main\n\n// 面试题\n"))
+	if m.Title != "" {
+		t.Fatalf("comment after package should be ignored: %+v", m)
+	}
+}
@@ -0,0 +1,235 @@
+package notestore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// ErrReadOnly 挂载点只读
+var ErrReadOnly = errors.New("note mount is read-only")
+
+// MountOptions 挂载点配置
+type MountOptions struct {
+	Name     string // 挂载名，即虚拟路径的第一级目录；只有一个挂载点时可以为空，表示挂载到根目录
+	Dir      string // 文件系统路径
+	ReadOnly bool
+	Hidden   bool // 不出现在根目录列表中，但仍然可以按路径访问
+}
+
+// Mount 已打开的挂载点
+type Mount struct {
+	MountOptions
+	Store *Store
+}
+
+// Multi 把多个 Store 挂载到同一棵虚拟目录树下，路径形如 <挂载名>/<相对路径>，
+// 方法与 Store 一一对应
+type Multi struct {
+	mounts []*Mount
+	byName map[string]*Mount
+}
+
+// OpenMulti 按顺序打开全部挂载点，任何一个失败都会关闭已打开的
+func OpenMulti(mounts []MountOptions, opt Options) (*Multi, error) {
+	if len(mounts) == 0 {
+		return nil, errors.New("no note mounts")
+	}
+	m := &Multi{byName: make(map[string]*Mount, len(mounts))}
+	for _, mo := range mounts {
+		if err := m.add(mo, opt, len(mounts)); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Multi) add(mo MountOptions, opt Options, total int) error {
+	switch {
+	case mo.Name == "" && total > 1:
+		return errors.New("mount name is required when there are multiple mounts")
+	case strings.ContainsAny(mo.Name, "/\\\x00") || strings.HasPrefix(mo.Name, "."):
+		return fmt.Errorf("invalid mount name %q", mo.Name)
+	case m.byName[mo.Name] != nil:
+		return fmt.Errorf("duplicate mount name %q", mo.Name)
+	}
+	s, err := Open(mo.Dir, opt)
+	if err != nil {
+		return err
+	}
+	mt := &Mount{MountOptions: mo, Store: s}
+	mt.Dir = s.Dir()
+	m.mounts = append(m.mounts, mt)
+	m.byName[mo.Name] = mt
+	return nil
+}
+
+// Mounts 按配置顺序返回全部挂载点
+func (m *Multi) Mounts() []*Mount {
+	return m.mounts
+}
+
+func (m *Multi) Close() error {
+	var errs []error
+	for _, mt := range m.mounts {
+		errs = append(errs, mt.Store.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// root 是否只有一个挂载到根目录的 Store
+func (m *Multi) root() *Mount {
+	if len(m.mounts) == 1 && m.mounts[0].Name == "" {
+		return m.mounts[0]
+	}
+	return nil
+}
+
+// Resolve 找到虚拟路径所在的挂载点，返回相对挂载点的路径；"" 表示虚拟根目录，此时挂载点为 nil
+func (m *Multi) Resolve(p string) (*Mount, string, error) {
+	if mt := m.root(); mt != nil {
+		return mt, p, nil
+	}
+	if strings.HasPrefix(p, "/") {
+		return nil, "", fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	p = strings.TrimSuffix(p, "/")
+	if p == "" || p == "." {
+		return nil, "", nil
+	}
+	name, rel, _ := strings.Cut(p, "/")
+	mt, ok := m.byName[name]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrNotFound, p)
+	}
+	return mt, rel, nil
+}
+
+// Join 挂载点内的相对路径转换为虚拟路径
+func (m *Multi) Join(mt *Mount, rel string) string {
+	switch {
+	case mt.Name == "":
+		return rel
+	case rel == "":
+		return mt.Name
+	default:
+		return mt.Name + "/" + rel
+	}
+}
+
+// Clean 校验并规范化虚拟路径
+func (m *Multi) Clean(p string) (string, error) {
+	mt, rel, err := m.Resolve(p)
+	if err != nil || mt == nil {
+		return "", err
+	}
+	if rel, err = mt.Store.Clean(rel); err != nil {
+		return "", err
+	}
+	return m.Join(mt, rel), nil
+}
+
+// Stat 虚拟根目录返回第一个挂载点的信息
+func (m *Multi) Stat(p string) (fs.FileInfo, error) {
+	mt, rel, err := m.Resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mt == nil {
+		return m.mounts[0].Store.Stat("")
+	}
+	return mt.Store.Stat(rel)
+}
+
+func (m *Multi) ReadFile(p string) ([]byte, fs.FileInfo, error) {
+	mt, rel, err := m.Resolve(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mt == nil {
+		return nil, nil, fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	return mt.Store.ReadFile(rel)
+}
+
+// ReadDir 虚拟根目录列出未隐藏的挂载点，按配置顺序排列
+func (m *Multi) ReadDir(p string) ([]fs.DirEntry, error) {
+	mt, rel, err := m.Resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mt != nil {
+		return mt.Store.ReadDir(rel)
+	}
+	var out []fs.DirEntry
+	for _, mt := range m.mounts {
+		if mt.Hidden {
+			continue
+		}
+		fi, err := mt.Store.Stat("")
+		if err != nil {
+			continue
+		}
+		out = append(out, fs.FileInfoToDirEntry(mountInfo{fi, mt.Name}))
+	}
+	return out, nil
+}
+
+// writable 写操作的挂载点
+func (m *Multi) writable(p string) (*Mount, string, error) {
+	mt, rel, err := m.Resolve(p)
+	if err != nil {
+		return nil, "", err
+	}
+	if mt == nil {
+		return nil, "", fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	if mt.ReadOnly {
+		return nil, "", fmt.Errorf("%w: %q", ErrReadOnly, p)
+	}
+	return mt, rel, nil
+}
+
+func (m *Multi) WriteFile(p string, data []byte) error {
+	mt, rel, err := m.writable(p)
+	if err != nil {
+		return err
+	}
+	return mt.Store.WriteFile(rel, data)
+}
+
+// Rename 只能在同一个挂载点内重命名
+func (m *Multi) Rename(oldPath, newPath string) error {
+	from, oldRel, err := m.writable(oldPath)
+	if err != nil {
+		return err
+	}
+	to, newRel, err := m.writable(newPath)
+	if err != nil {
+		return err
+	}
+	if from != to {
+		return fmt.Errorf("%w: rename %q across mounts", ErrForbidden, oldPath)
+	}
+	return from.Store.Rename(oldRel, newRel)
+}
+
+func (m *Multi) Remove(p string) error {
+	mt, rel, err := m.writable(p)
+	if err != nil {
+		return err
+	}
+	return mt.Store.Remove(rel)
+}
+
+// mountInfo 挂载点根目录的信息，名称替换为挂载名
+type mountInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i mountInfo) Name() string {
+	return i.name
+}
@@ -0,0 +1,97 @@
+package notestore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupMulti(t *testing.T) *Multi {
+	t.Helper()
+	base := t.TempDir()
+	for _, name := range []string{"work", "archive", "private"} {
+		os.MkdirAll(filepath.Join(base, name, "sub"), 0o755)
+		os.WriteFile(filepath.Join(base, name, "sub", "a.md"), []byte(name), 0o644)
+	}
+	m, err := OpenMulti([]MountOptions{
+		{Name: "work", Dir: filepath.Join(base, "work")},
+		{Name: "archive", Dir: filepath.Join(base, "archive"), ReadOnly: true},
+		{Name: "private", Dir: filepath.Join(base, "private"), Hidden: true},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestMultiRead(t *testing.T) {
+	m := setupMulti(t)
+	entries, err := m.ReadDir("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			t.Errorf("%s is not a directory", e.Name())
+		}
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "work" || names[1] != "archive" {
+		t.Errorf("root entries = %v", names)
+	}
+
+	for _, name := range []string{"work", "archive", "private"} {
+		b, _, err := m.ReadFile(name + "/sub/a.md")
+		if err != nil || string(b) != name {
+			t.Errorf("ReadFile(%s) = %q, %v", name, b, err)
+		}
+	}
+	for _, p := range []string{"", "work", "missing/sub/a.md", "/work/sub/a.md", "work/../archive/sub/a.md"} {
+		if _, _, err := m.ReadFile(p); err == nil {
+			t.Errorf("ReadFile(%q) succeeded", p)
+		}
+	}
+	if p, err := m.Clean("work/sub/./a.md"); err != nil || p != "work/sub/a.md" {
+		t.Errorf("Clean = %q, %v", p, err)
+	}
+}
+
+func TestMultiWrite(t *testing.T) {
+	m := setupMulti(t)
+	if err := m.WriteFile("work/new.md", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteFile("archive/new.md", nil); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("write read-only mount: %v", err)
+	}
+	if err := m.Remove("archive/sub/a.md"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("remove from read-only mount: %v", err)
+	}
+	if err := m.Rename("work/new.md", "private/new.md"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("rename across mounts: %v", err)
+	}
+	if err := m.Rename("work/new.md", "work/sub/new.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("work/sub/new.md"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMultiRoot(t *testing.T) {
+	s, _ := setup(t, Options{})
+	m, err := OpenMulti([]MountOptions{{Dir: s.Dir()}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if b, _, err := m.ReadFile("Golang/readme.md"); err != nil || string(b) != "# readme" {
+		t.Errorf("ReadFile = %q, %v", b, err)
+	}
+	if _, err := OpenMulti([]MountOptions{{Dir: s.Dir()}, {Name: "b", Dir: s.Dir()}}, Options{}); err == nil {
+		t.Error("unnamed mount accepted alongside others")
+	}
+}
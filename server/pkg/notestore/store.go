@@ -0,0 +1,341 @@
+package notestore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrNotFound  = errors.New("note not found")
+	ErrForbidden = errors.New("note path forbidden")
+	ErrTooLarge  = errors.New("note too large")
+)
+
+// SymlinkPolicy 符号链接处理策略
+type SymlinkPolicy int
+
+const (
+	SymlinkInRoot SymlinkPolicy = iota // 允许符号链接，但目标必须在根目录内
+	SymlinkDeny                        // 拒绝任何符号链接
+)
+
+type Options struct {
+	Symlinks    SymlinkPolicy
+	AllowHidden bool     // 是否允许访问 . 开头的文件和目录
+	AllowExt    []string // 允许的扩展名（含点，如 ".md"），为空表示不限制
+	DenyExt     []string // 禁止的扩展名，优先于 AllowExt
+	MaxSize     int64    // 单个文件最大字节数，<=0 表示不限制
+}
+
+// Store 以根目录为边界的笔记文件系统，所有路径都是相对根目录的 / 分隔路径
+type Store struct {
+	dir  string
+	root *os.Root
+	opt  Options
+}
+
+func Open(dir string, opt Options) (*Store, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open note root %s: %w", dir, err)
+	}
+	for i, ext := range opt.AllowExt {
+		opt.AllowExt[i] = strings.ToLower(ext)
+	}
+	for i, ext := range opt.DenyExt {
+		opt.DenyExt[i] = strings.ToLower(ext)
+	}
+	return &Store{dir: dir, root: root, opt: opt}, nil
+}
+
+// Dir 根目录的绝对路径
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) Close() error {
+	return s.root.Close()
+}
+
+// Clean 校验并规范化相对路径，"" 表示根目录
+func (s *Store) Clean(p string) (string, error) {
+	if strings.ContainsAny(p, "\x00\\") || strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	if p == "" || p == "." {
+		return "", nil
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("%w: %q", ErrForbidden, p)
+		}
+	}
+	p = path.Clean(p)
+	for _, seg := range strings.Split(p, "/") {
+		if !s.opt.AllowHidden && strings.HasPrefix(seg, ".") {
+			return "", fmt.Errorf("%w: %q", ErrForbidden, p)
+		}
+	}
+	return p, nil
+}
+
+// allowExt 扩展名黑白名单
+func (s *Store) allowExt(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range s.opt.DenyExt {
+		if e == ext {
+			return false
+		}
+	}
+	if len(s.opt.AllowExt) == 0 {
+		return true
+	}
+	for _, e := range s.opt.AllowExt {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLinks 按策略检查路径上的每一级是否为符号链接
+func (s *Store) checkLinks(p string) error {
+	if s.opt.Symlinks != SymlinkDeny || p == "" {
+		return nil
+	}
+	segs := strings.Split(p, "/")
+	for i := range segs {
+		fi, err := s.root.Lstat(strings.Join(segs[:i+1], "/"))
+		if err != nil {
+			return s.wrap(p, err)
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: symlink %q", ErrForbidden, p)
+		}
+	}
+	return nil
+}
+
+// wrap 统一错误类型，os.Root 拒绝越界访问时会返回非 NotExist 的错误
+func (s *Store) wrap(p string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %q", ErrNotFound, p)
+	}
+	return fmt.Errorf("%w: %q: %v", ErrForbidden, p, err)
+}
+
+func (s *Store) resolve(p string) (string, error) {
+	p, err := s.Clean(p)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkLinks(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func rootName(p string) string {
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// Stat 获取文件或目录信息
+func (s *Store) Stat(p string) (fs.FileInfo, error) {
+	p, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := s.root.Stat(rootName(p))
+	if err != nil {
+		return nil, s.wrap(p, err)
+	}
+	if !fi.IsDir() && !s.allowExt(p) {
+		return nil, fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	return fi, nil
+}
+
+// ReadFile 读取文件内容，目录、超出大小限制或被策略禁止的文件返回错误
+func (s *Store) ReadFile(p string) ([]byte, fs.FileInfo, error) {
+	p, err := s.resolve(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == "" || !s.allowExt(p) {
+		return nil, nil, fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	f, err := s.root.Open(p)
+	if err != nil {
+		return nil, nil, s.wrap(p, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, s.wrap(p, err)
+	}
+	if fi.IsDir() {
+		return nil, nil, fmt.Errorf("%w: %q is a directory", ErrNotFound, p)
+	}
+	if s.opt.MaxSize > 0 && fi.Size() > s.opt.MaxSize {
+		return nil, nil, fmt.Errorf("%w: %q (%d bytes)", ErrTooLarge, p, fi.Size())
+	}
+
+	r := io.Reader(f)
+	if s.opt.MaxSize > 0 {
+		r = io.LimitReader(f, s.opt.MaxSize+1)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.opt.MaxSize > 0 && int64(len(b)) > s.opt.MaxSize {
+		return nil, nil, fmt.Errorf("%w: %q", ErrTooLarge, p)
+	}
+	return b, fi, nil
+}
+
+// ReadDir 列出目录，按策略过滤隐藏文件、扩展名和符号链接；
+// 指向根目录内文件的符号链接按文件返回，目录链接一律跳过
+func (s *Store) ReadDir(p string) ([]fs.DirEntry, error) {
+	p, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.root.Open(rootName(p))
+	if err != nil {
+		return nil, s.wrap(p, err)
+	}
+	defer f.Close()
+
+	entries, err := f.ReadDir(-1)
+	if err != nil {
+		return nil, s.wrap(p, err)
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		name := e.Name()
+		if !s.opt.AllowHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if e.Type()&fs.ModeSymlink != 0 {
+			if s.opt.Symlinks == SymlinkDeny {
+				continue
+			}
+			fi, err := s.root.Stat(path.Join(rootName(p), name))
+			if err != nil || fi.IsDir() {
+				// 悬空、越界的链接，以及可能成环的目录链接
+				continue
+			}
+			e = fs.FileInfoToDirEntry(fi)
+		}
+		if !e.IsDir() && !s.allowExt(name) {
+			continue
+		}
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return out, nil
+}
+
+// checkWrite 写操作的路径校验，目标必须是允许的文件路径
+func (s *Store) checkWrite(p string) (string, error) {
+	p, err := s.Clean(p)
+	if err != nil {
+		return "", err
+	}
+	if p == "" || !s.allowExt(p) {
+		return "", fmt.Errorf("%w: %q", ErrForbidden, p)
+	}
+	// 已存在的部分路径不能是被禁止的符号链接
+	if err := s.checkLinks(p); err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	return p, nil
+}
+
+// WriteFile 创建或覆盖文件，自动创建父目录
+func (s *Store) WriteFile(p string, data []byte) error {
+	p, err := s.checkWrite(p)
+	if err != nil {
+		return err
+	}
+	if s.opt.MaxSize > 0 && int64(len(data)) > s.opt.MaxSize {
+		return fmt.Errorf("%w: %q (%d bytes)", ErrTooLarge, p, len(data))
+	}
+	if dir := path.Dir(p); dir != "." {
+		if err := s.root.MkdirAll(dir, 0o755); err != nil {
+			return s.wrap(p, err)
+		}
+	}
+	if err := s.root.WriteFile(p, data, 0o644); err != nil {
+		return s.wrap(p, err)
+	}
+	return nil
+}
+
+// Rename 重命名文件，目标已存在时返回 fs.ErrExist
+func (s *Store) Rename(oldPath, newPath string) error {
+	oldPath, err := s.checkWrite(oldPath)
+	if err != nil {
+		return err
+	}
+	newPath, err = s.checkWrite(newPath)
+	if err != nil {
+		return err
+	}
+	fi, err := s.root.Lstat(oldPath)
+	if err != nil {
+		return s.wrap(oldPath, err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%w: %q is a directory", ErrForbidden, oldPath)
+	}
+	if _, err := s.root.Lstat(newPath); err == nil {
+		return fmt.Errorf("rename %q: %w", newPath, fs.ErrExist)
+	}
+	if dir := path.Dir(newPath); dir != "." {
+		if err := s.root.MkdirAll(dir, 0o755); err != nil {
+			return s.wrap(newPath, err)
+		}
+	}
+	if err := s.root.Rename(oldPath, newPath); err != nil {
+		return s.wrap(oldPath, err)
+	}
+	return nil
+}
+
+// Remove 删除文件，不删除目录
+func (s *Store) Remove(p string) error {
+	p, err := s.checkWrite(p)
+	if err != nil {
+		return err
+	}
+	fi, err := s.root.Lstat(p)
+	if err != nil {
+		return s.wrap(p, err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%w: %q is a directory", ErrForbidden, p)
+	}
+	if err := s.root.Remove(p); err != nil {
+		return s.wrap(p, err)
+	}
+	return nil
+}
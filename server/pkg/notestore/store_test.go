@@ -0,0 +1,164 @@
+package notestore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T, opt Options) (*Store, string) {
+	t.Helper()
+	base := t.TempDir()
+	root := filepath.Join(base, "notefile")
+	os.MkdirAll(filepath.Join(root, "Golang", "yingyong"), 0o755)
+	os.MkdirAll(filepath.Join(root, ".git"), 0o755)
+	os.WriteFile(filepath.Join(root, "Golang", "yingyong", "context.go"), []byte("package main"), 0o644)
+	os.WriteFile(filepath.Join(root, "Golang", "readme.md"), []byte("# readme"), 0o644)
+	os.WriteFile(filepath.Join(root, ".env"), []byte("PASSWORD=123456"), 0o644)
+	os.WriteFile(filepath.Join(root, "big.text"), []byte(strings.Repeat("x", 100)), 0o644)
+	os.WriteFile(filepath.Join(base, "secret.txt"), []byte("secret"), 0o644)
+	os.Symlink(filepath.Join(base, "secret.txt"), filepath.Join(root, "escape.txt"))
+	os.Symlink(base, filepath.Join(root, "escapedir"))
+	os.Symlink("Golang/readme.md", filepath.Join(root, "link.md"))
+
+	s, err := Open(root, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, base
+}
+
+func TestTraversalRejected(t *testing.T) {
+	s, base := setup(t, Options{})
+	for _, p := range []string{
+		"../secret.txt",
+		"Golang/../../secret.txt",
+		"Golang/yingyong/../../../secret.txt",
+		"/etc/passwd",
+		base + "/secret.txt",
+		"..",
+		"..\\secret.txt",
+		"Golang\x00.md",
+		"escape.txt",
+		"escapedir/secret.txt",
+		".env",
+		".git/config",
+	} {
+		b, _, err := s.ReadFile(p)
+		if err == nil {
+			t.Errorf("ReadFile(%q) succeeded: %q", p, b)
+			continue
+		}
+		if !errors.Is(err, ErrForbidden) && !errors.Is(err, ErrNotFound) {
+			t.Errorf("ReadFile(%q) unexpected error: %v", p, err)
+		}
+	}
+	if _, err := s.ReadDir("../"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("ReadDir(../) err = %v", err)
+	}
+	if _, err := s.ReadDir("escapedir"); err == nil {
+		t.Errorf("ReadDir(escapedir) should fail")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	s, _ := setup(t, Options{MaxSize: 50})
+	b, fi, err := s.ReadFile("Golang/yingyong/context.go")
+	if err != nil || string(b) != "package main" || fi.Name() != "context.go" {
+		t.Fatalf("ReadFile: %q %v", b, err)
+	}
+	if _, _, err := s.ReadFile("Golang/./yingyong//context.go"); err != nil {
+		t.Fatalf("clean path: %v", err)
+	}
+	if _, _, err := s.ReadFile("link.md"); err != nil {
+		t.Fatalf("in-root symlink: %v", err)
+	}
+	if _, _, err := s.ReadFile("big.text"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("max size: %v", err)
+	}
+	if _, _, err := s.ReadFile("missing.md"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing: %v", err)
+	}
+	if _, _, err := s.ReadFile("Golang"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("directory: %v", err)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	s, _ := setup(t, Options{Symlinks: SymlinkDeny, DenyExt: []string{".GO"}})
+	if _, _, err := s.ReadFile("link.md"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("symlink deny: %v", err)
+	}
+	if _, _, err := s.ReadFile("Golang/yingyong/context.go"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("deny ext: %v", err)
+	}
+
+	entries, err := s.ReadDir("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, ","); got != "Golang,big.text" {
+		t.Fatalf("ReadDir filtered = %s", got)
+	}
+
+	s, _ = setup(t, Options{AllowExt: []string{".md"}, AllowHidden: true})
+	if _, _, err := s.ReadFile("big.text"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("allow ext: %v", err)
+	}
+	entries, _ = s.ReadDir("")
+	names = names[:0]
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// escapedir 指向根目录外，link.md 是指向内部文件的链接
+	if got := strings.Join(names, ","); got != ".git,Golang,link.md" {
+		t.Fatalf("ReadDir allow = %s", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s, base := setup(t, Options{MaxSize: 50, DenyExt: []string{".key"}})
+	if err := s.WriteFile("算法/栈/Stack.go", []byte("package main")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _, err := s.ReadFile("算法/栈/Stack.go"); err != nil || string(b) != "package main" {
+		t.Fatalf("read back: %q %v", b, err)
+	}
+	for _, p := range []string{"../x.md", "escapedir/x.md", ".env", "a.key", ""} {
+		if err := s.WriteFile(p, []byte("x")); !errors.Is(err, ErrForbidden) {
+			t.Errorf("WriteFile(%q) err = %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(base, "x.md")); err == nil {
+		t.Fatal("file written outside root")
+	}
+	if err := s.WriteFile("big.md", []byte(strings.Repeat("x", 51))); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("max size: %v", err)
+	}
+
+	if err := s.Rename("算法/栈/Stack.go", "Golang/readme.md"); err == nil {
+		t.Error("rename over existing file should fail")
+	}
+	if err := s.Rename("算法/栈/Stack.go", "../Stack.go"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("rename outside root: %v", err)
+	}
+	if err := s.Rename("算法/栈/Stack.go", "算法/Stack.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("Golang"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("remove dir: %v", err)
+	}
+	if err := s.Remove("算法/Stack.go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat("算法/Stack.go"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("removed file still exists: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package oidcstub 本地开发和测试用的 OIDC 提供方替身：不校验密码，在登录页直接选择用户，
+// 支持 discovery、授权码和 token 接口，ID Token 用 client secret 做 HS256 签名
+package oidcstub
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type User struct {
+	Name   string   `json:"preferred_username"`
+	Email  string   `json:"email,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Provider 处理 /.well-known/openid-configuration、/authorize 和 /token
+type Provider struct {
+	Issuer       string // 对外地址，如 http://localhost:9000
+	ClientID     string
+	ClientSecret string
+	Users        []User
+
+	mu    sync.Mutex
+	codes map[string]grant
+	mux   *http.ServeMux
+}
+
+// grant 已签发、尚未兑换的授权码
+type grant struct {
+	user        User
+	clientID    string
+	redirectURI string
+	nonce       string
+	expires     time.Time
+}
+
+var loginTpl = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8" /><title>OIDC 登录（本地替身）</title></head>
+<body>
+<h3>选择登录用户</h3>
+<ul>
+{{range .Users}}<li><a href="{{$.Base}}&user={{.Name}}">{{.Name}}</a> {{range .Groups}}<small>#{{.}}</small> {{end}}</li>
+{{end}}</ul>
+</body>
+</html>`))
+
+func New(issuer, clientID, clientSecret string, users ...User) *Provider {
+	p := &Provider{Issuer: issuer, ClientID: clientID, ClientSecret: clientSecret, Users: users, codes: make(map[string]grant)}
+	p.mux = http.NewServeMux()
+	p.mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("GET /authorize", p.authorize)
+	p.mux.HandleFunc("POST /token", p.token)
+	return p
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"HS256"},
+	})
+}
+
+// authorize 没有 user 参数时显示用户列表，选择后带授权码跳回客户端
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	name := q.Get("user")
+	if name == "" {
+		loginTpl.Execute(w, map[string]any{"Users": p.Users, "Base": "/authorize?" + q.Encode()})
+		return
+	}
+	var user *User
+	for i := range p.Users {
+		if p.Users[i].Name == name {
+			user = &p.Users[i]
+		}
+	}
+	if user == nil {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+
+	code := random()
+	p.mu.Lock()
+	p.codes[code] = grant{user: *user, clientID: p.ClientID, redirectURI: q.Get("redirect_uri"), nonce: q.Get("nonce"), expires: time.Now().Add(time.Minute)}
+	p.mu.Unlock()
+
+	u, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	rq := u.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	u.RawQuery = rq.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// token 授权码只能兑换一次
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	} else {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	}
+	if id != p.ClientID || secret != p.ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	code := r.FormValue("code")
+	p.mu.Lock()
+	g, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+	if !ok || time.Now().After(g.expires) || g.redirectURI != r.FormValue("redirect_uri") || r.FormValue("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":                p.Issuer,
+		"sub":                g.user.Name,
+		"aud":                g.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              g.nonce,
+		"preferred_username": g.user.Name,
+		"email":              g.user.Email,
+		"groups":             g.user.Groups,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": random(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.sign(claims),
+	})
+}
+
+func (p *Provider) sign(claims map[string]any) string {
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	s := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	m := hmac.New(sha256.New, []byte(p.ClientSecret))
+	m.Write([]byte(s))
+	return s + "." + enc.EncodeToString(m.Sum(nil))
+}
+
+func tokenError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func random() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
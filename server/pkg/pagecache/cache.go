@@ -0,0 +1,205 @@
+// Package pagecache 渲染结果的内存缓存：按字节数上限淘汰最久未使用的页面，
+// 响应时处理 ETag / Last-Modified 条件请求，并缓存压缩后的内容
+package pagecache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"node/pkg/compress"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Page 一个渲染好的页面，创建后不再修改
+type Page struct {
+	Body         []byte
+	ContentType  string
+	ETag         string // 带引号的强校验值，NewPage 根据内容生成
+	ModTime      time.Time
+	CacheControl string
+
+	mu      sync.Mutex
+	encoded map[string][]byte
+}
+
+func NewPage(body []byte, contentType string, modTime time.Time) *Page {
+	sum := sha256.Sum256(body)
+	return &Page{
+		Body:        body,
+		ContentType: contentType,
+		ETag:        `"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`,
+		ModTime:     modTime,
+	}
+}
+
+// size 缓存占用，包括已经生成的压缩内容
+func (p *Page) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.Body)
+	for _, b := range p.encoded {
+		n += len(b)
+	}
+	return n
+}
+
+// body 返回指定编码的内容，压缩结果只生成一次；grown 为本次新生成的字节数
+func (p *Page) body(encoding string) (b []byte, grown int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if b, ok := p.encoded[encoding]; ok {
+		return b, 0
+	}
+	if p.encoded == nil {
+		p.encoded = make(map[string][]byte)
+	}
+	b = compress.Encode(encoding, p.Body)
+	p.encoded[encoding] = b
+	return b, len(b)
+}
+
+// ServeHTTP 条件请求命中时返回 304，否则按协商结果输出压缩或原始内容
+func (p *Page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.serve(w, r)
+}
+
+func (p *Page) serve(w http.ResponseWriter, r *http.Request) (grown int) {
+	h := w.Header()
+	h.Set("ETag", p.ETag)
+	if !p.ModTime.IsZero() {
+		h.Set("Last-Modified", p.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if p.CacheControl != "" {
+		h.Set("Cache-Control", p.CacheControl)
+	}
+	compress.Vary(h)
+	if notModified(r, p) {
+		w.WriteHeader(http.StatusNotModified)
+		return 0
+	}
+
+	body, encoding := p.Body, ""
+	if len(p.Body) >= compress.MinSize && compress.Compressible(p.ContentType) {
+		if encoding = compress.Negotiate(r); encoding != "" {
+			body, grown = p.body(encoding)
+			h.Set("Content-Encoding", encoding)
+		}
+	}
+	h.Set("Content-Type", p.ContentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+	return grown
+}
+
+// notModified If-None-Match 优先于 If-Modified-Since
+func notModified(r *http.Request, p *Page) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == p.ETag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !p.ModTime.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !p.ModTime.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// Cache 并发安全的 LRU 缓存
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type entry struct {
+	key  string
+	page *Page
+	size int
+}
+
+// New maxBytes 为缓存的页面（含压缩内容）总字节数上限
+func New(maxBytes int) *Cache {
+	return &Cache{maxBytes: maxBytes, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *Cache) Get(key string) (*Page, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*entry).page, true
+}
+
+// Put 超过上限的单个页面不缓存
+func (c *Cache) Put(key string, p *Page) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	size := p.size()
+	if size > c.maxBytes {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry{key: key, page: p, size: size})
+	c.bytes += size
+	c.evict()
+}
+
+// Serve 输出页面并更新缓存占用，页面第一次以某种编码输出时会增加占用
+func (c *Cache) Serve(w http.ResponseWriter, r *http.Request, key string, p *Page) {
+	if grown := p.serve(w, r); grown > 0 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if el, ok := c.items[key]; ok && el.Value.(*entry).page == p {
+			el.Value.(*entry).size += grown
+			c.bytes += grown
+			c.evict()
+		}
+	}
+}
+
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	clear(c.items)
+	c.bytes = 0
+}
+
+// Len 缓存的页面数
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *Cache) evict() {
+	for c.bytes > c.maxBytes {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
@@ -0,0 +1,66 @@
+package pagecache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditional(t *testing.T) {
+	mod := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := NewPage([]byte(strings.Repeat("note ", 1000)), "text/html; charset=utf-8", mod)
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/view/a.md", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("Accept-Encoding", "gzip")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" || w.Body.Len() >= len(p.Body) {
+		t.Fatalf("first response: %d %v", w.Code, w.Header())
+	}
+	if w.Header().Get("Last-Modified") != "Fri, 02 Jan 2026 03:04:05 GMT" {
+		t.Errorf("Last-Modified: %s", w.Header().Get("Last-Modified"))
+	}
+	if w := serve("If-None-Match", `"other", `+p.ETag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: %d", w.Code)
+	}
+	if w := serve("If-None-Match", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("stale ETag: %d", w.Code)
+	}
+	if w := serve("If-Modified-Since", mod.Format(http.TimeFormat)); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: %d", w.Code)
+	}
+	if w := serve("If-Modified-Since", mod.Add(-time.Second).Format(http.TimeFormat)); w.Code != http.StatusOK {
+		t.Errorf("older If-Modified-Since: %d", w.Code)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c := New(250)
+	page := func(s string) *Page { return NewPage([]byte(strings.Repeat(s, 100)), "text/plain", time.Time{}) }
+	c.Put("a", page("a"))
+	c.Put("b", page("b"))
+	c.Get("a")
+	c.Put("c", page("c"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("a should be kept")
+	}
+	c.Put("big", page("big"))
+	if _, ok := c.Get("big"); ok {
+		t.Error("page larger than the cache should not be stored")
+	}
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Len after Clear = %d", c.Len())
+	}
+}
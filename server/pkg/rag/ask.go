@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ChatFunc 单轮对话，如 ai.Client.Chat
+type ChatFunc func(ctx context.Context, system, prompt string) (string, error)
+
+// System 问答使用的系统提示词
+const System = "你是团队知识库的助手。只根据提供的笔记片段回答问题，使用与问题相同的语言；" +
+	"在用到某个片段的句子后用 [编号] 标注来源，如 [1]；片段中没有答案时直接说明笔记中没有相关内容，不要编造。"
+
+// NoAnswer 没有检索到相关段落时的回答，此时不调用模型
+const NoAnswer = "笔记中没有找到与问题相关的内容。"
+
+// Source 回答引用的段落，N 为提示词中的编号
+type Source struct {
+	N int `json:"n"`
+	Hit
+}
+
+type Answer struct {
+	Question string   `json:"question"`
+	Text     string   `json:"answer"`
+	Sources  []Source `json:"sources"`
+}
+
+// Asker 检索段落并调用模型回答
+type Asker struct {
+	Index   *Index
+	Chat    ChatFunc
+	Embed   EmbedFunc // 为空时只按关键词检索
+	Sources int       // 检索的段落数
+}
+
+// Ask 回答 question，只引用 keep 为 true 的笔记
+func (a *Asker) Ask(ctx context.Context, question string, keep func(p string) bool) (*Answer, error) {
+	question = strings.TrimSpace(question)
+	var qvec []float64
+	if a.Embed != nil {
+		vectors, err := a.Embed(ctx, []string{question})
+		if err != nil {
+			return nil, fmt.Errorf("embed question: %w", err)
+		}
+		qvec = vectors[0]
+	}
+	hits := a.Index.Search(question, qvec, a.Sources, keep)
+	answer := &Answer{Question: question, Sources: []Source{}}
+	if len(hits) == 0 {
+		answer.Text = NoAnswer
+		return answer, nil
+	}
+	for i, h := range hits {
+		answer.Sources = append(answer.Sources, Source{N: i + 1, Hit: h})
+	}
+	text, err := a.Chat(ctx, System, Prompt(question, answer.Sources))
+	if err != nil {
+		return nil, fmt.Errorf("chat: %w", err)
+	}
+	answer.Text = strings.TrimSpace(text)
+	return answer, nil
+}
+
+// Prompt 把编号的段落和问题拼成提示词
+func Prompt(question string, sources []Source) string {
+	sb := &strings.Builder{}
+	sb.WriteString("笔记片段：\n\n")
+	for _, s := range sources {
+		fmt.Fprintf(sb, "[%d] %s", s.N, s.Path)
+		if s.Heading != "" {
+			fmt.Fprintf(sb, " › %s", s.Heading)
+		}
+		fmt.Fprintf(sb, "（第 %d 行）\n%s\n\n", s.Line, s.Text)
+	}
+	sb.WriteString("问题：")
+	sb.WriteString(question)
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk 笔记中的一段，问答时作为引用来源
+type Chunk struct {
+	Path    string `json:"path"`
+	Heading string `json:"heading,omitempty"` // 所在的 Markdown 标题
+	Line    int    `json:"line"`              // 起始行号，从 1 开始
+	Text    string `json:"text"`
+}
+
+// Split 把笔记切分为不超过 maxRunes 个字符的段落：Markdown 在标题处断开，
+// 其他文件按空行分块；代码围栏内的空行和 # 不作为分隔
+func Split(p, content string, maxRunes int) []Chunk {
+	markdown := strings.EqualFold(path.Ext(p), ".md")
+	var (
+		chunks  []Chunk
+		heading string
+		cur     []string
+		start   int
+		runes   int
+		fence   bool
+	)
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(cur, "\n"))
+		if text != "" {
+			chunks = append(chunks, Chunk{Path: p, Heading: heading, Line: start, Text: text})
+		}
+		cur, runes = nil, 0
+	}
+	add := func(line string, num int) {
+		n := utf8.RuneCountInString(line) + 1
+		if runes > 0 && runes+n > maxRunes {
+			flush()
+		}
+		if len(cur) == 0 {
+			start = num
+		}
+		// 超长的单行按字符切开
+		for utf8.RuneCountInString(line) > maxRunes {
+			cut := byteOffset(line, maxRunes)
+			cur = append(cur, line[:cut])
+			flush()
+			line, start = line[cut:], num
+		}
+		cur = append(cur, line)
+		runes += utf8.RuneCountInString(line) + 1
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		num := i + 1
+		trimmed := strings.TrimSpace(line)
+		if markdown && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = !fence
+		}
+		switch {
+		case markdown && !fence && headingText(trimmed) != "":
+			flush()
+			heading = headingText(trimmed)
+			add(line, num)
+		case trimmed == "" && !fence:
+			// 段落之间的空行，当前段已经够长时在这里断开
+			if runes >= maxRunes/2 {
+				flush()
+			} else if len(cur) > 0 {
+				cur = append(cur, "")
+				runes++
+			}
+		default:
+			add(line, num)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// headingText Markdown 标题（# 到 ######）的文字，不是标题时返回空
+func headingText(line string) string {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || !strings.HasPrefix(line[level:], " ") {
+		return ""
+	}
+	return strings.TrimSpace(line[level:])
+}
+
+// byteOffset 第 n 个字符的字节偏移
+func byteOffset(s string, n int) int {
+	i := 0
+	for j := range s {
+		if i == n {
+			return j
+		}
+		i++
+	}
+	return len(s)
+}
@@ -0,0 +1,210 @@
+// Package rag 基于笔记的问答：把笔记切分为段落建立索引，按问题检索相关段落，
+// 连同编号交给模型回答，回答中用 [n] 标注引用的段落
+package rag
+
+import (
+	"context"
+	"math"
+	"node/pkg/search"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmbedFunc 批量计算文本向量，如 ai.Client.Embed
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// Hit 检索到的段落
+type Hit struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// Index 段落索引：关键词打分复用 search.Index，每个段落是其中一个文档；
+// 配置了向量模型时再按向量相似度混合排序
+type Index struct {
+	maxRunes int
+	lexical  *search.Index
+
+	mu      sync.RWMutex
+	files   map[string][]string // 笔记路径 -> 段落 key
+	chunks  map[string]Chunk
+	vectors map[string][]float64
+}
+
+// NewIndex maxRunes 为单个段落的最大字符数
+func NewIndex(maxRunes int) *Index {
+	return &Index{
+		maxRunes: maxRunes,
+		lexical:  search.New(),
+		files:    make(map[string][]string),
+		chunks:   make(map[string]Chunk),
+		vectors:  make(map[string][]float64),
+	}
+}
+
+// chunkKey 段落在 search.Index 中的路径，笔记路径参与关键词打分
+func chunkKey(p string, i int) string {
+	return p + "#" + strconv.Itoa(i)
+}
+
+// Update 重新切分笔记，之前的段落和向量一并替换
+func (ix *Index) Update(p, content string) {
+	chunks := Split(p, content, ix.maxRunes)
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(p)
+	keys := make([]string, len(chunks))
+	for i, c := range chunks {
+		keys[i] = chunkKey(p, i)
+		ix.chunks[keys[i]] = c
+		text := c.Text
+		if c.Heading != "" && !strings.Contains(text, c.Heading) {
+			text = c.Heading + "\n" + text
+		}
+		ix.lexical.Update(keys[i], time.Time{}, text)
+	}
+	ix.files[p] = keys
+}
+
+// Retain 只保留 keep 返回 true 的笔记
+func (ix *Index) Retain(keep func(p string) bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for p := range ix.files {
+		if !keep(p) {
+			ix.remove(p)
+		}
+	}
+}
+
+func (ix *Index) remove(p string) {
+	for _, key := range ix.files[p] {
+		ix.lexical.Remove(key)
+		delete(ix.chunks, key)
+		delete(ix.vectors, key)
+	}
+	delete(ix.files, p)
+}
+
+// Len 段落数
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.chunks)
+}
+
+// Embed 为还没有向量的段落计算向量，每批 batch 个；返回本次计算的段落数
+func (ix *Index) Embed(ctx context.Context, embed EmbedFunc, batch int) (int, error) {
+	ix.mu.RLock()
+	var keys []string
+	for key := range ix.chunks {
+		if _, ok := ix.vectors[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	ix.mu.RUnlock()
+	sort.Strings(keys)
+
+	done := 0
+	for len(keys) > 0 {
+		n := min(batch, len(keys))
+		part := keys[:n]
+		keys = keys[n:]
+
+		texts := make([]string, 0, n)
+		ix.mu.RLock()
+		for _, key := range part {
+			texts = append(texts, ix.chunks[key].Text)
+		}
+		ix.mu.RUnlock()
+		vectors, err := embed(ctx, texts)
+		if err != nil {
+			return done, err
+		}
+
+		ix.mu.Lock()
+		for i, key := range part {
+			// 计算期间笔记可能已经更新，段落文字不同时丢弃
+			if c, ok := ix.chunks[key]; ok && c.Text == texts[i] {
+				ix.vectors[key] = vectors[i]
+			}
+		}
+		ix.mu.Unlock()
+		done += n
+	}
+	return done, nil
+}
+
+// lexicalWeight 混合排序时关键词得分（归一化到 0~1）的权重，其余为向量相似度
+const lexicalWeight = 0.5
+
+// Search 返回与 query 最相关的 k 个段落，只包括 keep 为 true 的笔记；
+// qvec 为问题的向量，为空时只按关键词打分
+func (ix *Index) Search(query string, qvec []float64, k int, keep func(p string) bool) []Hit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	allowed := func(key string) bool {
+		c, ok := ix.chunks[key]
+		return ok && (keep == nil || keep(c.Path))
+	}
+	scores := make(map[string]float64)
+	results := ix.lexical.SearchFilter(query, max(4*k, 20), allowed)
+	if len(results) > 0 {
+		top := results[0].Score
+		for _, r := range results {
+			w := 1.0
+			if qvec != nil {
+				w = lexicalWeight
+			}
+			scores[r.Path] = w * r.Score / top
+		}
+	}
+	if qvec != nil {
+		for key, v := range ix.vectors {
+			if allowed(key) {
+				if sim := cosine(qvec, v); sim > 0 {
+					scores[key] += (1 - lexicalWeight) * sim
+				}
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, Hit{Chunk: ix.chunks[key], Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"context"
+	"net/http/httptest"
+	"node/pkg/ai"
+	"node/pkg/aistub"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	src := "# 标题\n第一段\n\n```go\n# 不是标题\n\nfunc main() {}\n```\n## 小节\n第二段\n"
+	chunks := Split("a.md", src, 200)
+	if len(chunks) != 2 || chunks[0].Heading != "标题" || chunks[0].Line != 1 || !strings.Contains(chunks[0].Text, "# 不是标题\n\nfunc main") {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+	if chunks[1].Heading != "小节" || chunks[1].Line != 9 || chunks[1].Text != "## 小节\n第二段" {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+
+	chunks = Split("a.go", "package a\n"+strings.Repeat("长", 25)+"\n", 20)
+	if len(chunks) != 3 || chunks[1].Text != strings.Repeat("长", 20) || chunks[2].Line != 2 || chunks[2].Heading != "" {
+		t.Fatalf("long line not split: %+v", chunks)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	srv := httptest.NewServer(aistub.New())
+	defer srv.Close()
+	client := ai.NewClient(ai.Config{BaseURL: srv.URL + "/v1", Model: "stub", EmbeddingModel: "stub"})
+
+	ix := NewIndex(200)
+	ix.Update("Golang/context.md", "# Context\nWithTimeout 在超时后取消 context。子 goroutine 通过 Done 退出\n")
+	ix.Update("Docker/build.md", "# 构建\ndocker build 使用多阶段构建减小镜像体积。\n")
+	ix.Update("secret.md", "# Context\ncontext 超时的内部笔记。\n")
+	if n, err := ix.Embed(context.Background(), client.Embed, 2); err != nil || n != 3 {
+		t.Fatalf("embed: n=%d err=%v", n, err)
+	}
+
+	asker := &Asker{Index: ix, Chat: client.Chat, Embed: client.Embed, Sources: 2}
+	keep := func(p string) bool { return p != "secret.md" }
+	answer, err := asker.Ask(context.Background(), "context 超时怎么取消？", keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answer.Sources) == 0 || answer.Sources[0].Path != "Golang/context.md" || answer.Sources[0].Heading != "Context" {
+		t.Fatalf("unexpected sources: %+v", answer.Sources)
+	}
+	for _, s := range answer.Sources {
+		if s.Path == "secret.md" {
+			t.Fatalf("filtered note cited: %+v", answer.Sources)
+		}
+	}
+	if answer.Text != "（本地模型替身）WithTimeout 在超时后取消 context [1]" {
+		t.Fatalf("unexpected answer: %q", answer.Text)
+	}
+
+	ix.Retain(keep)
+	answer, err = (&Asker{Index: ix, Chat: client.Chat, Sources: 2}).Ask(context.Background(), "kubernetes", nil)
+	if err != nil || answer.Text != NoAnswer || len(answer.Sources) != 0 {
+		t.Fatalf("no answer: %+v %v", answer, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package sandbox 在受限环境中编译并运行 Go 笔记：无网络，只能看到系统目录、工具链和临时工作目录，
+// 限制 CPU、内存、运行时间和输出大小
+package sandbox
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnavailable 当前系统无法隔离网络和文件系统（需要 Linux 非特权用户命名空间，以及 util-linux 的 unshare、pivot_root、setpriv）
+var ErrUnavailable = errors.New("sandbox: isolation is unavailable")
+
+// Mode 运行方式
+type Mode string
+
+const (
+	ModeRun  Mode = "run"  // go run 单个文件
+	ModeTest Mode = "test" // go test 文件所在的包
+)
+
+// Limits 资源限制
+type Limits struct {
+	Timeout time.Duration // 编译、运行各自的墙钟时间
+	CPU     time.Duration // 运行时的 CPU 时间
+	Memory  int64         // 运行时数据段大小（RLIMIT_DATA），Go 运行时会预留大量地址空间，不能用 RLIMIT_AS
+	Output  int           // stdout、stderr 合计输出字节数
+}
+
+var DefaultLimits = Limits{
+	Timeout: 30 * time.Second,
+	CPU:     10 * time.Second,
+	Memory:  256 << 20,
+	Output:  1 << 20,
+}
+
+// Event 运行过程中的事件
+type Event struct {
+	Type    string     `json:"type"` // build | stdout | stderr | test | exit | error
+	Data    string     `json:"data,omitempty"`
+	Test    *TestEvent `json:"test,omitempty"`
+	Code    int        `json:"code,omitempty"`    // exit 事件的退出码
+	Elapsed float64    `json:"elapsed,omitempty"` // exit 事件的耗时，秒
+}
+
+// TestEvent go test -v 输出中的测试结果
+type TestEvent struct {
+	Action  string  `json:"action"` // run | pass | fail | skip
+	Name    string  `json:"name"`
+	Elapsed float64 `json:"elapsed,omitempty"`
+}
+
+// Sandbox 复用本机的 Go 工具链和编译缓存
+type Sandbox struct {
+	Limits  Limits
+	goBin   string
+	goEnv   []string
+	version string
+	// 沙箱中只读挂载的目录，以及需要重建的符号链接（如 /bin -> usr/bin）
+	binds      []string
+	links      [][2]string
+	gocache    string
+	gomodcache string
+}
+
+// Runnable 文件是否为带 main 函数的 main 包
+func Runnable(name string, src []byte) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.SkipObjectResolution)
+	if err != nil || f.Name.Name != "main" {
+		return false
+	}
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageName 返回文件的包名，解析失败返回空
+func PackageName(name string, src []byte) string {
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.PackageClauseOnly)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(f.Name.Name, "_test")
+}
+
+// parseTest 解析 go test -v 的结果行
+func parseTest(line string) *TestEvent {
+	trimmed := strings.TrimSpace(line)
+	if name, ok := strings.CutPrefix(trimmed, "=== RUN "); ok {
+		return &TestEvent{Action: "run", Name: strings.TrimSpace(name)}
+	}
+	for _, action := range []string{"PASS", "FAIL", "SKIP"} {
+		rest, ok := strings.CutPrefix(trimmed, "--- "+action+": ")
+		if !ok {
+			continue
+		}
+		t := &TestEvent{Action: strings.ToLower(action), Name: rest}
+		// --- PASS: TestLRU (0.00s)
+		if i := strings.LastIndex(rest, " ("); i > 0 && strings.HasSuffix(rest, "s)") {
+			t.Name = rest[:i]
+			t.Elapsed, _ = strconv.ParseFloat(rest[i+2:len(rest)-2], 64)
+		}
+		return t
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// New 检查 Go 工具链以及网络、文件系统隔离是否可用
+func New(limits Limits) (*Sandbox, error) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: %w", err)
+	}
+	s := &Sandbox{Limits: limits, goBin: goBin}
+	out, err := exec.Command(goBin, "env", "GOVERSION", "GOCACHE", "GOMODCACHE", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: go env: %w", err)
+	}
+	env := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(env) != 4 {
+		return nil, fmt.Errorf("sandbox: unexpected go env output %q", out)
+	}
+	s.version = strings.TrimPrefix(env[0], "go")
+	s.gocache, s.gomodcache = env[1], env[2]
+	// 只读挂载系统目录和工具链，配置文件、笔记目录等其他路径在沙箱中不可见
+	s.binds = []string{"/usr", env[3], filepath.Dir(goBin)}
+	for _, p := range []string{"/bin", "/sbin", "/lib", "/lib32", "/lib64"} {
+		if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Readlink(p); err == nil {
+				s.links = append(s.links, [2]string{p, target})
+			}
+		} else if err == nil && fi.IsDir() {
+			s.binds = append(s.binds, p)
+		}
+	}
+	if err := s.probe(); err != nil {
+		return nil, ErrUnavailable
+	}
+
+	s.goEnv = []string{
+		"GOCACHE=" + env[1],
+		"GOMODCACHE=" + env[2],
+		"GOROOT=" + env[3],
+		"PATH=" + filepath.Join(env[3], "bin") + ":/usr/bin:/bin",
+		"GOTOOLCHAIN=local",
+		"GOPROXY=off",
+		"GOFLAGS=-mod=mod",
+		"CGO_ENABLED=0",
+	}
+	return s, nil
+}
+
+// Run 编译并运行 files（文件名 -> 内容）。ModeRun 只能包含一个文件，ModeTest 为同一个包的全部文件。
+// 编译输出、程序输出和测试结果通过 emit 依次返回，ctx 取消时终止进程
+func (s *Sandbox) Run(ctx context.Context, mode Mode, files map[string][]byte, emit func(Event)) error {
+	// stdout、stderr 在不同的 goroutine 中读取，保证 emit 不会被并发调用
+	var emitMu sync.Mutex
+	unsafeEmit := emit
+	emit = func(e Event) {
+		emitMu.Lock()
+		defer emitMu.Unlock()
+		unsafeEmit(e)
+	}
+
+	dir, err := os.MkdirTemp("", "note-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	// TMPDIR 不能是模块根目录，否则 go 命令会忽略其中的 go.mod；root 是沙箱根目录的挂载点
+	for _, sub := range []string{"tmp", "root"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	for name, src := range files {
+		if name != filepath.Base(name) || !strings.HasSuffix(name, ".go") {
+			return fmt.Errorf("sandbox: invalid file name %q", name)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+			return err
+		}
+	}
+	gomod := fmt.Sprintf("module notes\n\ngo %s\n", s.version)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644); err != nil {
+		return err
+	}
+
+	build := []string{s.goBin, "build", "-o", "prog", "."}
+	run := []string{"./prog"}
+	if mode == ModeTest {
+		build = []string{s.goBin, "test", "-c", "-o", "prog", "."}
+		run = []string{"./prog", "-test.v", "-test.timeout", s.Limits.Timeout.String()}
+	}
+
+	// 编译同样在无网络的环境中进行，依赖只能来自本机的模块缓存；只有编译时可以写编译缓存
+	emit(Event{Type: "build", Data: strings.Join(build[1:], " ")})
+	mounts := []mount{{path: s.gomodcache}, {path: s.gocache, rw: true}}
+	if code, _, err := s.exec(ctx, dir, mounts, nil, build, func(stream, line string) bool {
+		emit(Event{Type: "build", Data: line})
+		return true
+	}); err != nil || code != 0 {
+		emit(Event{Type: "exit", Code: code})
+		return err
+	}
+
+	limit := fmt.Sprintf("ulimit -t %d && ulimit -d %d && ulimit -f %d && exec \"$@\"",
+		max(1, int(s.Limits.CPU.Seconds())), s.Limits.Memory>>10, 10<<10)
+	env := []string{"GOMAXPROCS=2", "GOMEMLIMIT=" + strconv.FormatInt(s.Limits.Memory*3/4, 10)}
+	cmd := append([]string{"/bin/sh", "-c", limit, "sh"}, run...)
+
+	var (
+		mu      sync.Mutex
+		written int
+	)
+	start := time.Now()
+	code, killed, err := s.exec(ctx, dir, nil, env, cmd, func(stream, line string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if written += len(line) + 1; written > s.Limits.Output {
+			return false
+		}
+		emit(Event{Type: stream, Data: line})
+		if mode == ModeTest && stream == "stdout" {
+			if t := parseTest(line); t != nil {
+				emit(Event{Type: "test", Test: t})
+			}
+		}
+		return true
+	})
+	if killed != "" {
+		emit(Event{Type: "error", Data: killed})
+	}
+	emit(Event{Type: "exit", Code: code, Elapsed: time.Since(start).Seconds()})
+	return err
+}
+
+// mount 除系统目录外额外挂载到沙箱中的目录
+type mount struct {
+	path string
+	rw   bool
+}
+
+// probe 在隔离环境中执行 true，检查用户、网络、挂载和 PID 命名空间是否可用
+func (s *Sandbox) probe() error {
+	dir, err := os.MkdirTemp("", "note-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "root"), 0o755); err != nil {
+		return err
+	}
+	args := s.isolate(dir, nil, []string{"true"})
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = []string{"PATH=/usr/bin:/bin"}
+	return cmd.Run()
+}
+
+// isolate 返回在隔离环境中执行 args 的完整命令：unshare 创建新的用户、网络、挂载和 PID 命名空间，
+// 在 dir/root 上挂载只读的 tmpfs 作为根目录，只绑定系统目录、工具链、mounts 和工作目录 dir，
+// pivot_root 后卸载原来的根目录，最后去掉全部 capability，进程无法再修改挂载
+func (s *Sandbox) isolate(dir string, mounts []mount, args []string) []string {
+	root := filepath.Join(dir, "root")
+	// 每条命令单独一行，set -e 保证任何一步失败都不会在未隔离的环境中执行 args
+	var b strings.Builder
+	b.WriteString("set -e\npath=$PATH\nPATH=/usr/sbin:/usr/bin:/sbin:/bin\n")
+	fmt.Fprintf(&b, "r=%s\n", quote(root))
+	b.WriteString("mount -t tmpfs -o mode=755,size=1m tmpfs \"$r\"\n")
+	bind := func(p string, rw bool) {
+		fmt.Fprintf(&b, "mkdir -p \"$r\"%s\n", quote(p))
+		fmt.Fprintf(&b, "mount --bind %[1]s \"$r\"%[1]s\n", quote(p))
+		if !rw {
+			fmt.Fprintf(&b, "mount -o remount,bind,ro \"$r\"%s\n", quote(p))
+		}
+	}
+	for _, p := range s.binds {
+		bind(p, false)
+	}
+	for _, l := range s.links {
+		fmt.Fprintf(&b, "ln -s %s \"$r\"%s\n", quote(l[1]), quote(l[0]))
+	}
+	for _, m := range mounts {
+		bind(m.path, m.rw)
+	}
+	bind(dir, true)
+	b.WriteString("mkdir \"$r/dev\" \"$r/proc\" \"$r/.old\"\n")
+	for _, d := range []string{"null", "zero", "random", "urandom"} {
+		fmt.Fprintf(&b, "touch \"$r/dev/%s\"\n", d)
+		fmt.Fprintf(&b, "mount --bind /dev/%[1]s \"$r/dev/%[1]s\"\n", d)
+	}
+	b.WriteString("mount -t proc proc \"$r/proc\"\n")
+	b.WriteString("cd \"$r\"\npivot_root . .old\numount -l /.old\nrmdir /.old\nmount -o remount,ro /\n")
+	fmt.Fprintf(&b, "cd %s\n", quote(dir))
+	b.WriteString("PATH=$path\nexec setpriv --no-new-privs --inh-caps=-all --bounding-set=-all -- \"$@\"\n")
+
+	isolate := []string{"unshare", "--user", "--map-root-user", "--net", "--mount", "--pid", "--fork", "--kill-child",
+		"/bin/sh", "-c", b.String(), "sh"}
+	return append(isolate, args...)
+}
+
+// quote 单引号转义，用于拼接 shell 脚本
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exec 在隔离环境中执行命令，按行回调输出，回调返回 false 时终止进程；返回退出码以及被终止的原因
+func (s *Sandbox) exec(ctx context.Context, dir string, mounts []mount, env, args []string, line func(stream, line string) bool) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Limits.Timeout)
+	defer cancel()
+	var overflow atomic.Bool
+
+	args = s.isolate(dir, mounts, args)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(append([]string{"HOME=" + dir, "TMPDIR=" + filepath.Join(dir, "tmp")}, s.goEnv...), env...)
+	// 整个进程组一起终止，避免测试中启动的子进程残留
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return -1, "", err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return -1, "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return -1, "", err
+	}
+
+	wg := &sync.WaitGroup{}
+	for stream, r := range map[string]io.Reader{"stdout": stdout, "stderr": stderr} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sc := bufio.NewScanner(r)
+			sc.Buffer(make([]byte, 64<<10), 1<<20)
+			for sc.Scan() {
+				if !line(stream, sc.Text()) && !overflow.Swap(true) {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	err = cmd.Wait()
+
+	var killed string
+	switch {
+	case overflow.Load():
+		killed = fmt.Sprintf("killed: output limit %d bytes exceeded", s.Limits.Output)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		killed = fmt.Sprintf("killed: timeout after %s", s.Limits.Timeout)
+	case ctx.Err() != nil:
+		killed = "killed: canceled"
+	case cmd.ProcessState != nil:
+		if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			switch ws.Signal() {
+			case syscall.SIGXCPU, syscall.SIGKILL:
+				killed = fmt.Sprintf("killed: cpu limit %s exceeded", s.Limits.CPU)
+			case syscall.SIGXFSZ:
+				killed = "killed: file size limit exceeded"
+			default:
+				killed = "killed: " + ws.Signal().String()
+			}
+		}
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return -1, killed, err
+	}
+	return cmd.ProcessState.ExitCode(), killed, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sandbox
+
+import "context"
+
+// New 网络和文件系统隔离依赖 Linux 用户命名空间，其他系统不支持运行笔记
+func New(limits Limits) (*Sandbox, error) {
+	return nil, ErrUnavailable
+}
+
+func (s *Sandbox) Run(ctx context.Context, mode Mode, files map[string][]byte, emit func(Event)) error {
+	return ErrUnavailable
+}
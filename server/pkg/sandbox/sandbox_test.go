@@ -0,0 +1,174 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSandbox(t *testing.T, limits Limits) *Sandbox {
+	s, err := New(limits)
+	if errors.Is(err, ErrUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func collect(t *testing.T, s *Sandbox, mode Mode, files map[string][]byte) []Event {
+	var events []Event
+	if err := s.Run(context.Background(), mode, files, func(e Event) { events = append(events, e) }); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func output(events []Event, typ string) string {
+	var lines []string
+	for _, e := range events {
+		if e.Type == typ {
+			lines = append(lines, e.Data)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRun(t *testing.T) {
+	s := newSandbox(t, DefaultLimits)
+	src := []byte(`package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+func main() {
+	fmt.Println("hello")
+	if _, err := net.Dial("tcp", "1.1.1.1:80"); err == nil {
+		fmt.Println("network reachable")
+	}
+	fmt.Fprintln(os.Stderr, "bye")
+	os.Exit(3)
+}
+`)
+	if !Runnable("main.go", src) {
+		t.Fatal("main.go should be runnable")
+	}
+	events := collect(t, s, ModeRun, map[string][]byte{"main.go": src})
+	if got := output(events, "stdout"); got != "hello" {
+		t.Errorf("stdout: %q", got)
+	}
+	if got := output(events, "stderr"); got != "bye" {
+		t.Errorf("stderr: %q", got)
+	}
+	if last := events[len(events)-1]; last.Type != "exit" || last.Code != 3 {
+		t.Errorf("exit: %+v", last)
+	}
+}
+
+func TestFilesystem(t *testing.T) {
+	s := newSandbox(t, DefaultLimits)
+	secret := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(secret, []byte("session_secret = 'x'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 沙箱外的文件不可见，系统目录只读，环境变量中没有服务端的配置
+	src := []byte(`package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func main() {
+	if _, err := os.ReadFile(` + "`" + secret + "`" + `); err == nil {
+		fmt.Println("secret readable")
+	}
+	if _, err := os.Stat("/etc/passwd"); err == nil {
+		fmt.Println("etc visible")
+	}
+	if err := os.WriteFile("/usr/x", nil, 0o644); err == nil {
+		fmt.Println("usr writable")
+	}
+	if err := syscall.Mount("", "/usr", "", syscall.MS_REMOUNT|syscall.MS_BIND, ""); err == nil {
+		fmt.Println("remount allowed")
+	}
+	if os.Getenv("SECRET_FROM_SERVER") != "" {
+		fmt.Println("env leaked")
+	}
+	fmt.Println("done")
+}
+`)
+	t.Setenv("SECRET_FROM_SERVER", "1")
+	events := collect(t, s, ModeRun, map[string][]byte{"main.go": src})
+	if got := output(events, "stdout"); got != "done" {
+		t.Errorf("stdout: %q\n%+v", got, events)
+	}
+}
+
+func TestTest(t *testing.T) {
+	s := newSandbox(t, DefaultLimits)
+	files := map[string][]byte{
+		"add.go":      []byte("package calc\n\nfunc Add(a, b int) int { return a + b }\n"),
+		"add_test.go": []byte("package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {}\n\nfunc TestBad(t *testing.T) { t.Fatal(Add(1, 1)) }\n"),
+	}
+	if PackageName("add_test.go", files["add_test.go"]) != "calc" || Runnable("add.go", files["add.go"]) {
+		t.Fatal("unexpected package detection")
+	}
+	var results []string
+	for _, e := range collect(t, s, ModeTest, files) {
+		if e.Type == "test" && e.Test.Action != "run" {
+			results = append(results, e.Test.Action+" "+e.Test.Name)
+		}
+	}
+	if strings.Join(results, ",") != "pass TestAdd,fail TestBad" {
+		t.Errorf("results: %v", results)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	s := newSandbox(t, Limits{Timeout: 5 * time.Second, CPU: time.Second, Memory: 64 << 20, Output: 100})
+	events := collect(t, s, ModeRun, map[string][]byte{"main.go": []byte(`package main
+
+import "fmt"
+
+func main() {
+	for i := 0; ; i++ {
+		fmt.Println(i)
+	}
+}
+`)})
+	if got := output(events, "error"); !strings.Contains(got, "output limit") {
+		t.Errorf("errors: %q", got)
+	}
+
+	events = collect(t, s, ModeRun, map[string][]byte{"main.go": []byte(`package main
+
+func main() {
+	var s [][]byte
+	for {
+		b := make([]byte, 16<<20)
+		for i := range b {
+			b[i] = 1
+		}
+		s = append(s, b)
+	}
+}
+`)})
+	if last := events[len(events)-1]; last.Code == 0 {
+		t.Errorf("memory limit not enforced: %+v", events)
+	}
+
+	events = collect(t, s, ModeRun, map[string][]byte{"main.go": []byte("package main\n\nfunc main() {\n\tundefined()\n}\n")})
+	if !strings.Contains(output(events, "build"), "undefined") || events[len(events)-1].Code == 0 {
+		t.Errorf("build error: %+v", events)
+	}
+}
@@ -0,0 +1,263 @@
+package search
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	bm25K1     = 1.2 // BM25 参数
+	bm25B      = 0.75
+	pathWeight = 3 // 路径/文件名中的词权重
+	snippetLen = 160
+)
+
+type document struct {
+	path    string
+	modTime time.Time
+	content string
+	length  int
+	terms   map[string]int
+}
+
+// Result 搜索结果
+type Result struct {
+	Path    string        `json:"path"`
+	Score   float64       `json:"score"`
+	Snippet template.HTML `json:"snippet"`
+}
+
+// Index 内存倒排索引，并发安全
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]*document
+	postings map[string]map[string]int // term -> path -> tf
+	totalLen int
+}
+
+func New() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// ModTime 返回已索引文档的修改时间，用于增量索引判断
+func (ix *Index) ModTime(path string) (time.Time, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	d, ok := ix.docs[path]
+	if !ok {
+		return time.Time{}, false
+	}
+	return d.modTime, true
+}
+
+// Update 添加或替换文档
+func (ix *Index) Update(path string, modTime time.Time, content string) {
+	d := &document{
+		path:    path,
+		modTime: modTime,
+		content: content,
+		terms:   make(map[string]int),
+	}
+	for _, t := range Tokenize(content) {
+		d.terms[t.Term]++
+		d.length++
+	}
+	for _, t := range Tokenize(path) {
+		d.terms[t.Term] += pathWeight
+		d.length += pathWeight
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(path)
+	ix.docs[path] = d
+	ix.totalLen += d.length
+	for term, tf := range d.terms {
+		p, ok := ix.postings[term]
+		if !ok {
+			p = make(map[string]int)
+			ix.postings[term] = p
+		}
+		p[path] = tf
+	}
+}
+
+// Remove 删除文档
+func (ix *Index) Remove(path string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(path)
+}
+
+func (ix *Index) remove(path string) {
+	d, ok := ix.docs[path]
+	if !ok {
+		return
+	}
+	for term := range d.terms {
+		p := ix.postings[term]
+		delete(p, path)
+		if len(p) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen -= d.length
+	delete(ix.docs, path)
+}
+
+// Retain 只保留 keep 返回 true 的文档，用于清理已删除的文件
+func (ix *Index) Retain(keep func(path string) bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path := range ix.docs {
+		if !keep(path) {
+			ix.remove(path)
+		}
+	}
+}
+
+// Len 已索引的文档数
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search 按 BM25 打分返回前 limit 条结果，limit<=0 时返回全部
+func (ix *Index) Search(query string, limit int) []Result {
+	return ix.SearchFilter(query, limit, nil)
+}
+
+// SearchFilter 同 Search，只返回 keep 为 true 的文档，keep 为空时不过滤
+func (ix *Index) SearchFilter(query string, limit int, keep func(path string) bool) []Result {
+	terms := make(map[string]struct{})
+	for _, t := range TokenizeQuery(query) {
+		terms[t.Term] = struct{}{}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := float64(len(ix.docs))
+	if n == 0 {
+		return nil
+	}
+	avgLen := float64(ix.totalLen) / n
+
+	scores := make(map[string]float64)
+	for term := range terms {
+		p := ix.postings[term]
+		if len(p) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(len(p))+0.5)/(float64(len(p))+0.5))
+		for path, tf := range p {
+			dl := float64(ix.docs[path].length)
+			f := float64(tf)
+			scores[path] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgLen))
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for path, score := range scores {
+		if keep != nil && !keep(path) {
+			continue
+		}
+		results = append(results, Result{Path: path, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Snippet = snippet(ix.docs[results[i].Path].content, terms)
+	}
+	return results
+}
+
+// snippet 截取第一个命中词附近的文本，命中词用 <mark> 高亮
+func snippet(content string, terms map[string]struct{}) template.HTML {
+	var hits []Token
+	for _, t := range Tokenize(content) {
+		if _, ok := terms[t.Term]; ok {
+			hits = append(hits, t)
+		}
+	}
+	hits = mergeHits(hits)
+
+	start := 0
+	if len(hits) > 0 {
+		start = backRunes(content, hits[0].Start, snippetLen/4)
+	}
+	end := forwardRunes(content, start, snippetLen)
+
+	buf := &strings.Builder{}
+	if start > 0 {
+		buf.WriteString("…")
+	}
+	pos := start
+	for _, h := range hits {
+		if h.Start < pos || h.End > end {
+			continue
+		}
+		buf.WriteString(html.EscapeString(content[pos:h.Start]))
+		buf.WriteString("<mark>")
+		buf.WriteString(html.EscapeString(content[h.Start:h.End]))
+		buf.WriteString("</mark>")
+		pos = h.End
+	}
+	buf.WriteString(html.EscapeString(content[pos:end]))
+	if end < len(content) {
+		buf.WriteString("…")
+	}
+	return template.HTML(buf.String())
+}
+
+// mergeHits 按位置排序并合并重叠的命中区间（中文二元组、标识符子词会互相重叠）
+func mergeHits(hits []Token) []Token {
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Start < hits[j].Start })
+	merged := hits[:0]
+	for _, h := range hits {
+		if n := len(merged); n > 0 && h.Start <= merged[n-1].End {
+			if h.End > merged[n-1].End {
+				merged[n-1].End = h.End
+			}
+			continue
+		}
+		merged = append(merged, h)
+	}
+	return merged
+}
+
+func backRunes(s string, i, n int) int {
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return i
+}
+
+func forwardRunes(s string, i, n int) int {
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
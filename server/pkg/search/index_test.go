@@ -0,0 +1,81 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func terms(tokens []Token) []string {
+	var out []string
+	for _, t := range tokens {
+		out = append(out, t.Term)
+	}
+	return out
+}
+
+func TestTokenizeIdent(t *testing.T) {
+	got := strings.Join(terms(Tokenize("NewWorkerPool http_server HTTPServer")), ",")
+	want := "newworkerpool,new,worker,pool,http_server,http,server,httpserver,http,server"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestTokenizeCJK(t *testing.T) {
+	got := strings.Join(terms(TokenizeQuery("面试八股文")), ",")
+	if got != "面试,试八,八股,股文" {
+		t.Fatalf("query tokens: %s", got)
+	}
+	got = strings.Join(terms(TokenizeQuery("栈")), ",")
+	if got != "栈" {
+		t.Fatalf("single rune query: %s", got)
+	}
+	for _, tk := range Tokenize("Go面试") {
+		if tk.Term == "面试" && tk.Start != 2 {
+			t.Fatalf("bad offset %+v", tk)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := New()
+	now := time.Now()
+	ix.Update("Golang/面试八股文/场景题.go", now, "// 面试常见场景题\nfunc NewWorkerPool() {}")
+	ix.Update("Golang/yingyong/context.go", now, "context.WithCancel 取消信号")
+	ix.Update("Docker/compose.text", now, "docker compose up -d")
+
+	res := ix.Search("面试", 10)
+	if len(res) != 1 || res[0].Path != "Golang/面试八股文/场景题.go" {
+		t.Fatalf("unexpected results: %+v", res)
+	}
+	if !strings.Contains(string(res[0].Snippet), "<mark>面试</mark>") {
+		t.Fatalf("snippet not highlighted: %s", res[0].Snippet)
+	}
+
+	res = ix.Search("worker pool", 10)
+	if len(res) != 1 || !strings.Contains(string(res[0].Snippet), "New<mark>WorkerPool</mark>") {
+		t.Fatalf("identifier search: %+v", res)
+	}
+
+	res = ix.Search("context", 10)
+	if len(res) == 0 || res[0].Path != "Golang/yingyong/context.go" {
+		t.Fatalf("path match should rank first: %+v", res)
+	}
+
+	res = ix.SearchFilter("docker context", 10, func(p string) bool { return !strings.HasPrefix(p, "Docker/") })
+	if len(res) != 1 || res[0].Path != "Golang/yingyong/context.go" {
+		t.Fatalf("filtered results: %+v", res)
+	}
+
+	ix.Update("Docker/compose.text", now, "<script>compose</script>")
+	res = ix.Search("compose", 1)
+	if strings.Contains(string(res[0].Snippet), "<script>") {
+		t.Fatalf("snippet not escaped: %s", res[0].Snippet)
+	}
+
+	ix.Retain(func(path string) bool { return !strings.HasPrefix(path, "Docker/") })
+	if ix.Len() != 2 || len(ix.Search("docker", 10)) != 0 {
+		t.Fatalf("retain did not drop documents")
+	}
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token 分词结果，Start/End 为原文中的字节偏移
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+func isWord(r rune) bool {
+	return r == '_' || ((unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r))
+}
+
+// Tokenize 对文档分词
+//   - 英文/标识符：整词小写，同时按下划线和驼峰拆出子词（NewWorkerPool -> newworkerpool, new, worker, pool）
+//   - 中日韩文字：单字 + 相邻二元组（面试八股文 -> 面, 试, ..., 面试, 试八, ...）
+func Tokenize(text string) []Token {
+	return tokenize(text, true)
+}
+
+// TokenizeQuery 对查询分词，中文连续两字及以上只取二元组，避免单字匹配过宽
+func TokenizeQuery(text string) []Token {
+	return tokenize(text, false)
+}
+
+func tokenize(text string, unigrams bool) []Token {
+	var tokens []Token
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isWord(r):
+			start := i
+			for i < len(text) {
+				r, size = utf8.DecodeRuneInString(text[i:])
+				if !isWord(r) {
+					break
+				}
+				i += size
+			}
+			tokens = appendWord(tokens, text[start:i], start)
+		case isCJK(r):
+			start := i
+			for i < len(text) {
+				r, size = utf8.DecodeRuneInString(text[i:])
+				if !isCJK(r) {
+					break
+				}
+				i += size
+			}
+			tokens = appendCJK(tokens, text[start:i], start, unigrams)
+		default:
+			i += size
+		}
+	}
+	return tokens
+}
+
+func appendWord(tokens []Token, word string, offset int) []Token {
+	whole := strings.ToLower(word)
+	tokens = append(tokens, Token{Term: whole, Start: offset, End: offset + len(word)})
+
+	parts := splitIdent(word)
+	if len(parts) < 2 {
+		return tokens
+	}
+	for _, p := range parts {
+		tokens = append(tokens, Token{Term: strings.ToLower(p.text), Start: offset + p.start, End: offset + p.start + len(p.text)})
+	}
+	return tokens
+}
+
+type part struct {
+	text  string
+	start int
+}
+
+// splitIdent 按下划线、驼峰以及字母数字边界拆分标识符，HTTPServer -> HTTP, Server
+func splitIdent(word string) []part {
+	var (
+		parts []part
+		runes = []rune(word)
+		start = -1
+		pos   = 0 // 当前 rune 的字节偏移
+		begin = 0 // 当前子词起始字节偏移
+	)
+	flush := func(end int) {
+		if start >= 0 && end > begin {
+			parts = append(parts, part{text: word[begin:end], start: begin})
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if r == '_' {
+			flush(pos)
+			pos += utf8.RuneLen(r)
+			continue
+		}
+		if start >= 0 {
+			prev := runes[i-1]
+			boundary := false
+			switch {
+			case unicode.IsLower(prev) && unicode.IsUpper(r):
+				boundary = true
+			case unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				boundary = true
+			case unicode.IsDigit(prev) != unicode.IsDigit(r):
+				boundary = true
+			}
+			if boundary {
+				flush(pos)
+			}
+		}
+		if start < 0 {
+			start = i
+			begin = pos
+		}
+		pos += utf8.RuneLen(r)
+	}
+	flush(pos)
+	return parts
+}
+
+func appendCJK(tokens []Token, run string, offset int, unigrams bool) []Token {
+	var starts []int
+	for i := range run {
+		starts = append(starts, i)
+	}
+	starts = append(starts, len(run))
+	n := len(starts) - 1
+
+	if n == 1 || unigrams {
+		for i := 0; i < n; i++ {
+			tokens = append(tokens, Token{Term: run[starts[i]:starts[i+1]], Start: offset + starts[i], End: offset + starts[i+1]})
+		}
+	}
+	for i := 0; i+1 < n; i++ {
+		tokens = append(tokens, Token{Term: run[starts[i]:starts[i+2]], Start: offset + starts[i], End: offset + starts[i+2]})
+	}
+	return tokens
+}
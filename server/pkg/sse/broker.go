@@ -0,0 +1,99 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event 一条 Server-Sent Event
+type Event struct {
+	Name string
+	Data string
+}
+
+// Broker 把事件广播给所有已连接的浏览器
+type Broker struct {
+	mu        sync.Mutex
+	clients   map[chan Event]struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewBroker() *Broker {
+	return &Broker{clients: make(map[chan Event]struct{}), closed: make(chan struct{})}
+}
+
+// Close 断开全部长连接；http.Server.Shutdown 会一直等待进行中的请求，需要在 RegisterOnShutdown 中调用
+func (b *Broker) Close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// Publish 广播事件，客户端缓冲已满时丢弃，不阻塞发布方
+func (b *Broker) Publish(name, data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- Event{Name: name, Data: data}:
+		default:
+		}
+	}
+}
+
+func (b *Broker) subscribe() chan Event {
+	ch := make(chan Event, 16)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *Broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+// ServeHTTP 保持长连接推送事件，每 30 秒发送一次注释行防止代理断开空闲连接
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// 长连接不受 http.Server.WriteTimeout 限制
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.closed:
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev := <-ch:
+			if ev.Name != "" {
+				fmt.Fprintf(w, "event: %s\n", ev.Name)
+			}
+			for _, line := range strings.Split(ev.Data, "\n") {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprint(w, "\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package watcher
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+type inotify struct {
+	fd   int
+	file *os.File
+	mu   sync.Mutex
+	wds  map[int32]string // watch descriptor -> 目录
+}
+
+func (w *Watcher) start() error {
+	// IN_NONBLOCK 后交给 Go 的 netpoller，Close 时 Read 能立即返回
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+	in := &inotify{
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		wds:  make(map[int32]string),
+	}
+	if err := in.addTree(w.root); err != nil {
+		in.file.Close()
+		return err
+	}
+	w.closer = in.file.Close
+	go in.readLoop(w)
+	return nil
+}
+
+// addTree 递归监听目录，inotify 本身不支持递归
+func (in *inotify) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// .git 等隐藏目录变化频繁且不展示，不监听
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(in.fd, path, watchMask)
+		if err != nil {
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		in.mu.Lock()
+		in.wds[int32(wd)] = path
+		in.mu.Unlock()
+		return nil
+	})
+}
+
+func (in *inotify) readLoop(w *Watcher) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				log.Println("watcher.read.err:", err)
+			}
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+nameLen]
+			off += syscall.SizeofInotifyEvent + nameLen
+
+			name := string(nameBytes)
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			in.handle(w, wd, mask, name)
+		}
+	}
+}
+
+func (in *inotify) handle(w *Watcher, wd int32, mask uint32, name string) {
+	in.mu.Lock()
+	dir, ok := in.wds[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(in.wds, wd)
+	}
+	in.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	switch {
+	case mask&syscall.IN_Q_OVERFLOW != 0:
+		// 事件队列溢出，只能整体刷新
+		w.notify(w.root)
+		return
+	case mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0:
+		w.notify(filepath.Dir(dir))
+		return
+	case mask&syscall.IN_IGNORED != 0:
+		return
+	}
+
+	// 新建或移入的子目录需要追加监听
+	if mask&syscall.IN_ISDIR != 0 && strings.HasPrefix(name, ".") {
+		return
+	}
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if err := in.addTree(filepath.Join(dir, name)); err != nil {
+			log.Println("watcher.add.err:", err)
+		}
+	}
+	w.notify(dir)
+}
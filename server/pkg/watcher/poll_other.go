@@ -0,0 +1,79 @@
+//go:build !linux
+
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 非 Linux 平台没有 inotify，定时扫描目录并比较签名
+func (w *Watcher) start() error {
+	prev, err := snapshot(w.root)
+	if err != nil {
+		return err
+	}
+	interval := 2 * time.Second
+	if w.delay > interval {
+		interval = w.delay
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-t.C:
+			}
+			cur, err := snapshot(w.root)
+			if err != nil {
+				continue
+			}
+			for dir, sig := range cur {
+				if prev[dir] != sig {
+					w.notify(dir)
+				}
+			}
+			for dir := range prev {
+				if _, ok := cur[dir]; !ok {
+					w.notify(filepath.Dir(dir))
+				}
+			}
+			prev = cur
+		}
+	}()
+	return nil
+}
+
+// snapshot 目录 -> 子项名称、大小、修改时间拼接的签名
+func snapshot(root string) (map[string]string, error) {
+	sigs := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil
+		}
+		sb := &strings.Builder{}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(sb, "%s|%d|%d;", e.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+		sigs[path] = sb.String()
+		return nil
+	})
+	return sigs, err
+}
@@ -0,0 +1,105 @@
+package watcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watcher 监听 root 下所有目录的变化，合并 delay 时间内的连续变化后，
+// 通过 Events() 一次性推送发生变化的目录（绝对路径，已排序去重）
+type Watcher struct {
+	root   string
+	delay  time.Duration
+	raw    chan string
+	events chan []string
+	done   chan struct{}
+	once   sync.Once
+	closer func() error
+}
+
+// New 创建并启动监听，Linux 下基于 inotify，其他平台退化为定时扫描
+func New(root string, delay time.Duration) (*Watcher, error) {
+	if delay <= 0 {
+		delay = 300 * time.Millisecond
+	}
+	w := &Watcher{
+		root:   root,
+		delay:  delay,
+		raw:    make(chan string, 256),
+		events: make(chan []string, 1),
+		done:   make(chan struct{}),
+	}
+	if err := w.start(); err != nil {
+		return nil, err
+	}
+	go w.debounce()
+	return w, nil
+}
+
+// Events 变化目录的通知通道，Close 后关闭
+func (w *Watcher) Events() <-chan []string {
+	return w.events
+}
+
+// Close 停止监听
+func (w *Watcher) Close() (err error) {
+	w.once.Do(func() {
+		close(w.done)
+		if w.closer != nil {
+			err = w.closer()
+		}
+	})
+	return err
+}
+
+// notify 由平台实现调用，上报一个发生变化的目录
+func (w *Watcher) notify(dir string) {
+	select {
+	case w.raw <- dir:
+	case <-w.done:
+	}
+}
+
+// debounce 合并短时间内的多次变化，持续变化时最多等待 10 倍 delay
+func (w *Watcher) debounce() {
+	defer close(w.events)
+
+	var (
+		pending = make(map[string]struct{})
+		timer   = time.NewTimer(w.delay)
+		fire    <-chan time.Time
+		first   time.Time
+	)
+	timer.Stop()
+
+	for {
+		select {
+		case dir := <-w.raw:
+			if len(pending) == 0 {
+				first = time.Now()
+			}
+			pending[dir] = struct{}{}
+			if time.Since(first) < 10*w.delay {
+				timer.Reset(w.delay)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			dirs := make([]string, 0, len(pending))
+			for d := range pending {
+				dirs = append(dirs, d)
+			}
+			sort.Strings(dirs)
+			pending = make(map[string]struct{})
+			select {
+			case w.events <- dirs:
+			case <-w.done:
+				return
+			}
+		case <-w.done:
+			timer.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, w *Watcher, dir string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case dirs := <-w.Events():
+			if slices.Contains(dirs, dir) {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no event for %s", dir)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "Golang")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(root, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	os.WriteFile(filepath.Join(sub, "a.go"), []byte("package a"), 0o644)
+	waitFor(t, w, sub)
+
+	// 新建目录后其中的变化也要能收到
+	nested := filepath.Join(sub, "yingyong")
+	os.Mkdir(nested, 0o755)
+	waitFor(t, w, sub)
+	time.Sleep(100 * time.Millisecond)
+	os.WriteFile(filepath.Join(nested, "b.go"), []byte("package b"), 0o644)
+	waitFor(t, w, nested)
+
+	w.Close()
+	if _, ok := <-w.Events(); ok {
+		// 关闭前可能还有一个未读的批次
+		if _, ok := <-w.Events(); ok {
+			t.Fatal("events channel not closed")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package wikilink
+
+import (
+	"html"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Link 笔记中的 [[target#anchor|label]] 链接
+type Link struct {
+	Target string `json:"target"`
+	Anchor string `json:"anchor,omitempty"`
+	Label  string `json:"label,omitempty"`
+	Line   int    `json:"line"`
+}
+
+// Text 链接显示的文字，未指定 label 时使用目标路径
+func (l Link) Text() string {
+	if l.Label != "" {
+		return l.Label
+	}
+	if l.Anchor != "" {
+		return l.Target + "#" + l.Anchor
+	}
+	return l.Target
+}
+
+// Parse 解析 [[ ]] 之间的内容
+func Parse(inner string) (Link, bool) {
+	var l Link
+	inner, l.Label, _ = strings.Cut(inner, "|")
+	l.Target, l.Anchor, _ = strings.Cut(inner, "#")
+	l.Target = strings.TrimSpace(l.Target)
+	l.Anchor = strings.TrimSpace(l.Anchor)
+	l.Label = strings.TrimSpace(l.Label)
+	if l.Target == "" || strings.ContainsAny(l.Target, "[]\n") {
+		return Link{}, false
+	}
+	return l, true
+}
+
+var pattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// Extract 提取全部链接；Markdown 代码块和行内代码中的内容不算链接
+func Extract(name string, src []byte) []Link {
+	markdown := strings.EqualFold(path.Ext(name), ".md")
+	var (
+		links []Link
+		fence string
+	)
+	for i, line := range strings.Split(string(src), "\n") {
+		if markdown {
+			t := strings.TrimSpace(line)
+			if fence != "" {
+				if strings.HasPrefix(t, fence) {
+					fence = ""
+				}
+				continue
+			}
+			if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+				fence = t[:3]
+				continue
+			}
+			line = stripCode(line)
+		}
+		for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+			if l, ok := Parse(m[1]); ok {
+				l.Line = i + 1
+				links = append(links, l)
+			}
+		}
+	}
+	return links
+}
+
+// stripCode 去掉 `行内代码`
+func stripCode(line string) string {
+	if strings.IndexByte(line, '`') < 0 {
+		return line
+	}
+	sb := &strings.Builder{}
+	in := false
+	for _, s := range strings.Split(line, "`") {
+		if !in {
+			sb.WriteString(s)
+		}
+		sb.WriteByte(' ')
+		in = !in
+	}
+	return sb.String()
+}
+
+// escaped 匹配转义后 HTML 中的链接，用于高亮后的代码
+var escaped = regexp.MustCompile(`\[\[([^\[\]<>\n]+)\]\]`)
+
+// Linkify 把已转义 HTML 中的 [[ ]] 替换为超链接，href 为空时保留原文
+func Linkify(s string, href func(l Link) (string, bool)) string {
+	return escaped.ReplaceAllStringFunc(s, func(m string) string {
+		l, ok := Parse(html.UnescapeString(m[2 : len(m)-2]))
+		if !ok {
+			return m
+		}
+		h, ok := href(l)
+		if h == "" {
+			return m
+		}
+		class := "wikilink"
+		if !ok {
+			class += " broken"
+		}
+		return `<a class="` + class + `" href="` + html.EscapeString(h) + `">` + m + `</a>`
+	})
+}
+
+// Resolver 把链接目标解析为笔记路径
+type Resolver struct {
+	files  map[string]struct{}
+	byName map[string][]string
+}
+
+// NewResolver files 为全部笔记的相对路径
+func NewResolver(files []string) *Resolver {
+	r := &Resolver{files: make(map[string]struct{}, len(files)), byName: make(map[string][]string)}
+	for _, f := range files {
+		r.files[f] = struct{}{}
+		name := path.Base(f)
+		r.byName[name] = append(r.byName[name], f)
+	}
+	for _, fs := range r.byName {
+		sort.Strings(fs)
+	}
+	return r
+}
+
+// Resolve 依次尝试：相对笔记根目录、相对当前笔记所在目录及其上级目录、唯一的同名文件；
+// 没有扩展名时再补 .md 尝试一遍
+func (r *Resolver) Resolve(from, target string) (string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false
+	}
+	candidates := []string{target}
+	if path.Ext(target) == "" {
+		candidates = append(candidates, target+".md")
+	}
+	for _, t := range candidates {
+		if p, ok := r.resolve(from, t); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func (r *Resolver) resolve(from, target string) (string, bool) {
+	abs := strings.HasPrefix(target, "/")
+	p := path.Clean("/" + target)[1:]
+	if _, ok := r.files[p]; ok {
+		return p, true
+	}
+	if abs {
+		return "", false
+	}
+	// 从当前目录逐级向上，挂载点中的笔记可以用相对挂载点根目录的路径互相链接
+	for dir := path.Dir(from); dir != "."; dir = path.Dir(dir) {
+		rel := path.Clean("/" + dir + "/" + target)[1:]
+		if _, ok := r.files[rel]; ok {
+			return rel, true
+		}
+	}
+	if !strings.Contains(target, "/") {
+		if fs := r.byName[target]; len(fs) == 1 {
+			return fs[0], true
+		}
+	}
+	return "", false
+}
+
+// Backlinks links 中链接到 to 的笔记，按路径排序
+func Backlinks(r *Resolver, links map[string][]Link, to string) []string {
+	var froms []string
+	for from, ls := range links {
+		if from == to {
+			continue
+		}
+		for _, l := range ls {
+			if p, ok := r.Resolve(from, l.Target); ok && p == to {
+				froms = append(froms, from)
+				break
+			}
+		}
+	}
+	sort.Strings(froms)
+	return froms
+}
@@ -0,0 +1,56 @@
+package wikilink
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	src := "see [[Golang/yingyong/context.go]] and [[ note#Usage | 用法 ]]\n" +
+		"```\n[[in/code]]\n```\n" +
+		"`[[inline]]` [[]] [[a[b]]\n" +
+		"last [[x.md]]"
+	got := fmt.Sprint(Extract("a.md", []byte(src)))
+	want := "[{Golang/yingyong/context.go   1} {note Usage 用法 1} {x.md   6}]"
+	if got != want {
+		t.Fatalf("got %s", got)
+	}
+	if n := len(Extract("a.go", []byte("// [[in/code]]\n"))); n != 1 {
+		t.Fatalf("go file links: %d", n)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := NewResolver([]string{"Golang/yingyong/context.go", "Golang/readme.md", "Docker/readme.md", "Docker/build.md"})
+	cases := []struct {
+		from, target, want string
+	}{
+		{"x.md", "Golang/yingyong/context.go", "Golang/yingyong/context.go"},
+		{"x.md", "/Golang/readme", "Golang/readme.md"},
+		{"Docker/a.md", "build", "Docker/build.md"},
+		{"Docker/a.md", "../Golang/readme.md", "Golang/readme.md"},
+		{"Golang/yingyong/context.go", "readme", "Golang/readme.md"}, // 上级目录
+		{"Golang/yingyong/x.md", "yingyong/context.go", "Golang/yingyong/context.go"},
+		{"x.md", "context.go", "Golang/yingyong/context.go"},
+		{"x.md", "readme", ""}, // 同名文件不唯一
+		{"x.md", "../../etc/passwd", ""},
+	}
+	for _, c := range cases {
+		if got, _ := r.Resolve(c.from, c.target); got != c.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", c.from, c.target, got, c.want)
+		}
+	}
+}
+
+func TestLinkify(t *testing.T) {
+	got := Linkify(`<span class="com">// [[a&amp;b.go|x]] [[missing]]</span>`, func(l Link) (string, bool) {
+		if l.Target == "a&b.go" {
+			return "/view/a%26b.go", true
+		}
+		return "/edit/" + l.Target, false
+	})
+	want := `<span class="com">// <a class="wikilink" href="/view/a%26b.go">[[a&amp;b.go|x]]</a> <a class="wikilink broken" href="/edit/missing">[[missing]]</a></span>`
+	if got != want {
+		t.Fatalf("got %s", got)
+	}
+}
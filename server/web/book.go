@@ -0,0 +1,332 @@
+package web
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"node/pkg/highlight"
+	"node/pkg/markdown"
+	"node/pkg/notemeta"
+	"node/pkg/notestore"
+	"node/pkg/wikilink"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BookOptions 导出电子书的选项
+type BookOptions struct {
+	Dir   string              // 导出的目录，如 "算法"；为空时导出全部笔记
+	Title string              // 书名，为空时使用目录名
+	Keep  func(p string) bool // 为空时导出目录下的全部笔记，服务端按访问规则过滤
+	Store *notestore.Multi
+}
+
+// Book 一个目录导出的电子书，章节按目录树顺序排列，每篇笔记一章
+type Book struct {
+	Title    string
+	Dir      string
+	Modified time.Time // 最新的笔记修改时间
+	Parts    []*BookPart
+	Chapters []*Chapter
+	Images   []*BookImage
+}
+
+// BookPart 同一目录下的章节，目录页按它分组
+type BookPart struct {
+	Dir      string // 相对于 Book.Dir 的目录，根目录为空
+	Chapters []*Chapter
+}
+
+// Chapter 一篇笔记；HTML 中的链接按 EPUB 的文件组织：其他章节为 ch002.xhtml#锚点，图片为 images/001.png
+type Chapter struct {
+	ID         string // ch001
+	File       string // ch001.xhtml
+	Path       string
+	Title      string
+	Meta       notemeta.Meta
+	IsMarkdown bool
+	TOC        []markdown.Heading
+	HTML       template.HTML
+}
+
+// BookImage 笔记引用的图片
+type BookImage struct {
+	Path string
+	File string // images/001.png
+	Type string
+	Data []byte
+}
+
+// BuildBook 读取目录下的笔记并渲染为章节：[[ ]] 链接和指向书中其他笔记的相对链接改为章节内跳转，
+// 图片打包进书中；指向书外笔记的链接标记为失效，其他附件保留原地址
+func BuildBook(opt BookOptions) (*Book, error) {
+	dir := strings.Trim(opt.Dir, "/")
+	b := &Book{Title: opt.Title, Dir: dir}
+	if b.Title == "" {
+		b.Title = path.Base(dir)
+		if dir == "" {
+			b.Title = "笔记"
+		}
+	}
+	keep := opt.Keep
+	if keep == nil {
+		keep = func(string) bool { return true }
+	}
+	bb := &bookBuilder{book: b, store: opt.Store, keep: keep, files: make(map[string]*Chapter), images: make(map[string]*BookImage)}
+	if err := bb.walk(dir); err != nil {
+		return nil, err
+	}
+	if len(b.Chapters) == 0 {
+		return nil, fmt.Errorf("no notes in %q", dir)
+	}
+	if err := bb.render(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type bookBuilder struct {
+	book   *Book
+	store  *notestore.Multi
+	keep   func(p string) bool
+	paths  []string
+	files  map[string]*Chapter
+	images map[string]*BookImage
+}
+
+// walk 先收集当前目录的笔记，再进入子目录，同一目录的笔记在目录页中是一组
+func (bb *bookBuilder) walk(dir string) error {
+	entries, err := bb.store.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	part := &BookPart{Dir: strings.TrimPrefix(strings.TrimPrefix(dir, bb.book.Dir), "/")}
+	var dirs []string
+	for _, d := range entries {
+		p := d.Name()
+		if dir != "" {
+			p = dir + "/" + p
+		}
+		if !bb.keep(p) {
+			continue
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			continue
+		}
+		if _, ok := AttachmentType(p); ok {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		n := len(bb.book.Chapters) + 1
+		c := &Chapter{ID: fmt.Sprintf("ch%03d", n), Path: p, Title: d.Name(), IsMarkdown: strings.HasSuffix(p, ".md")}
+		c.File = c.ID + ".xhtml"
+		bb.book.Chapters = append(bb.book.Chapters, c)
+		part.Chapters = append(part.Chapters, c)
+		bb.paths = append(bb.paths, p)
+		bb.files[p] = c
+		if info.ModTime().After(bb.book.Modified) {
+			bb.book.Modified = info.ModTime()
+		}
+	}
+	if len(part.Chapters) > 0 {
+		bb.book.Parts = append(bb.book.Parts, part)
+	}
+	for _, p := range dirs {
+		if err := bb.walk(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bb *bookBuilder) render() error {
+	resolver := wikilink.NewResolver(bb.paths)
+	for _, c := range bb.book.Chapters {
+		b, _, err := bb.store.ReadFile(c.Path)
+		if err != nil {
+			return err
+		}
+		meta, body := notemeta.Parse(c.Path, b)
+		c.Meta = meta
+		if meta.Title != "" {
+			c.Title = meta.Title
+		}
+		link := bb.wikiLinks(resolver, c.Path)
+		if c.IsMarkdown {
+			doc, err := markdown.Render(body, markdown.WithLinkResolver(link), markdown.WithAssetResolver(bb.assets(c.Path)))
+			if err != nil {
+				return fmt.Errorf("render %s: %w", c.Path, err)
+			}
+			c.HTML = xmlEntities(doc.HTML)
+			c.TOC = doc.TOC
+		} else {
+			c.HTML = template.HTML(wikilink.Linkify(string(highlight.Render(highlight.Lang(c.Path), string(b))), link))
+		}
+	}
+	return nil
+}
+
+// wikiLinks [[ ]] 链接指向书中的章节，书外的笔记按失效链接处理
+func (bb *bookBuilder) wikiLinks(r *wikilink.Resolver, from string) markdown.LinkResolver {
+	return func(l wikilink.Link) (string, bool) {
+		p, ok := r.Resolve(from, l.Target)
+		if !ok {
+			return "#", false
+		}
+		href := bb.files[p].File
+		if l.Anchor != "" {
+			href += "#" + url.PathEscape(markdown.Slug(l.Anchor))
+		}
+		return href, true
+	}
+}
+
+// assets 相对地址按 Site.Attachments 的规则解析：图片打包进书中，书中笔记改为章节链接
+func (bb *bookBuilder) assets(from string) markdown.AssetResolver {
+	dir := path.Dir(from)
+	return func(dest string, image bool) (string, bool) {
+		u, err := url.Parse(dest)
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+			return "", false
+		}
+		p := path.Join(dir, u.Path)
+		if c, ok := bb.files[p]; ok {
+			href := c.File
+			if u.Fragment != "" {
+				href += "#" + u.EscapedFragment()
+			}
+			return href, true
+		}
+		if !image {
+			return "", false
+		}
+		if img := bb.image(p); img != nil {
+			return img.File, true
+		}
+		return "", false
+	}
+}
+
+// image 读取图片，同一张图片只打包一次；不可见或读取失败时返回 nil
+func (bb *bookBuilder) image(p string) *BookImage {
+	if img, ok := bb.images[p]; ok {
+		return img
+	}
+	bb.images[p] = nil
+	t, ok := AttachmentType(p)
+	if !ok || !strings.HasPrefix(t, "image/") || !bb.keep(p) {
+		return nil
+	}
+	b, _, err := bb.store.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	img := &BookImage{Path: p, Type: t, Data: b, File: fmt.Sprintf("images/%03d%s", len(bb.book.Images)+1, strings.ToLower(path.Ext(p)))}
+	bb.book.Images = append(bb.book.Images, img)
+	bb.images[p] = img
+	return img
+}
+
+var namedEntity = regexp.MustCompile(`&[A-Za-z][A-Za-z0-9]*;`)
+
+// xmlEntities Markdown 原文中的 &nbsp; 等命名实体在 XHTML 中未定义，替换为字符本身
+func xmlEntities(s template.HTML) template.HTML {
+	return template.HTML(namedEntity.ReplaceAllStringFunc(string(s), func(e string) string {
+		switch e {
+		case "&amp;", "&lt;", "&gt;", "&quot;", "&apos;":
+			return e
+		}
+		if r := html.UnescapeString(e); r != e {
+			return html.EscapeString(r)
+		}
+		return "&amp;" + e[1:]
+	}))
+}
+
+// BookData book.html 的数据
+type BookData struct {
+	*Book
+	CSS      template.CSS
+	Sections []BookSection
+}
+
+// BookSection 单文件 HTML 中的一章，锚点都加上章节 ID 前缀，避免不同笔记的标题锚点冲突
+type BookSection struct {
+	*Chapter
+	HTML template.HTML
+	TOC  []markdown.Heading
+}
+
+var (
+	chapterRef = regexp.MustCompile(`^(ch\d+)\.xhtml(?:#(.*))?$`)
+	// refAttr 章节 HTML 中的 id、href 和 src 属性，值已经过转义，不含双引号
+	refAttr = regexp.MustCompile(`(\s)(id|href|src)="([^"]*)"`)
+)
+
+// WriteHTML 输出单个 HTML 文件：目录、全部章节、样式和图片（data URI）都在文件内，离线可读
+func (b *Book) WriteHTML(w io.Writer) error {
+	tpl, err := Parse("book.html")
+	if err != nil {
+		return err
+	}
+	css, err := Assets.ReadFile("static/book.css")
+	if err != nil {
+		return err
+	}
+	images := make(map[string]string, len(b.Images))
+	for _, img := range b.Images {
+		images[img.File] = "data:" + img.Type + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+	}
+	data := &BookData{Book: b, CSS: template.CSS(css)}
+	for _, c := range b.Chapters {
+		s := BookSection{Chapter: c, HTML: inlineChapter(c, images)}
+		for _, h := range c.TOC {
+			h.ID = c.ID + "-" + h.ID
+			s.TOC = append(s.TOC, h)
+		}
+		data.Sections = append(data.Sections, s)
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return fmt.Errorf("execute book.html: %w", err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// inlineChapter 把章节中的锚点加上章节前缀，章节链接改为页内跳转，图片改为 data URI
+func inlineChapter(c *Chapter, images map[string]string) template.HTML {
+	return template.HTML(refAttr.ReplaceAllStringFunc(string(c.HTML), func(m string) string {
+		sub := refAttr.FindStringSubmatch(m)
+		sp, name, v := sub[1], sub[2], sub[3]
+		switch {
+		case name == "id":
+			v = c.ID + "-" + v
+		case name == "src":
+			if uri, ok := images[v]; ok {
+				v = uri
+			}
+		case v == "#":
+		case strings.HasPrefix(v, "#"):
+			v = "#" + c.ID + "-" + v[1:]
+		default:
+			if ref := chapterRef.FindStringSubmatch(v); ref != nil {
+				v = "#" + ref[1]
+				if ref[2] != "" {
+					v += "-" + ref[2]
+				}
+			}
+		}
+		return sp + name + `="` + v + `"`
+	}))
+}
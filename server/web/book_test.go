@@ -0,0 +1,99 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"node/pkg/notestore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBook(t *testing.T) {
+	notes := t.TempDir()
+	os.MkdirAll(filepath.Join(notes, "算法", "sub"), 0o755)
+	os.MkdirAll(filepath.Join(notes, "算法", "img"), 0o755)
+	os.WriteFile(filepath.Join(notes, "算法", "a.md"), []byte("---\ntitle: 排序\n---\n## 快排\n"+
+		"![图](img/p.png) 见 [[sub/b.go]]、[b](sub/b.go#L2)、[[Golang/x]] 和 [下文](#快排)&nbsp;\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "算法", "sub", "b.go"), []byte("package sub\n\n// [[a.md#快排]]\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "算法", "sub", "secret.md"), []byte("secret\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "算法", "img", "p.png"), []byte("\x89PNG\r\n\x1a\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "other.md"), []byte("other\n"), 0o644)
+
+	store, err := notestore.OpenMulti([]notestore.MountOptions{{Dir: notes}}, StoreOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	b, err := BuildBook(BookOptions{Dir: "算法/", Store: store, Keep: func(p string) bool { return !strings.HasSuffix(p, "secret.md") }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Title != "算法" || len(b.Chapters) != 2 || len(b.Parts) != 2 || b.Parts[1].Dir != "sub" || len(b.Images) != 1 {
+		t.Fatalf("book: %+v", b)
+	}
+	a, code := b.Chapters[0], b.Chapters[1]
+	if a.Title != "排序" || code.File != "ch002.xhtml" {
+		t.Fatalf("chapters: %+v %+v", a, code)
+	}
+	for _, want := range []string{`src="images/001.png"`, `href="ch002.xhtml"`, `href="ch002.xhtml#L2"`, `class="wikilink broken"`, `href="#%E5%BF%AB%E6%8E%92"`, "\u00a0"} {
+		if !strings.Contains(string(a.HTML), want) {
+			t.Errorf("chapter missing %q:\n%s", want, a.HTML)
+		}
+	}
+	if strings.Contains(string(a.HTML), "&nbsp;") {
+		t.Errorf("named entity left in XHTML:\n%s", a.HTML)
+	}
+
+	epub := &bytes.Buffer{}
+	if err := b.WriteEPUB(epub); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(epub.Bytes()), int64(epub.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := zr.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Fatalf("first entry: %s %d", f.Name, f.Method)
+	}
+	files := make(map[string]bool)
+	for _, f := range zr.File {
+		files[f.Name] = true
+		if !strings.HasSuffix(f.Name, ".xhtml") && !strings.HasSuffix(f.Name, ".opf") && !strings.HasSuffix(f.Name, ".ncx") {
+			continue
+		}
+		// 阅读器按 XML 解析，不能有未闭合的标签或未定义的实体
+		rc, _ := f.Open()
+		d := xml.NewDecoder(rc)
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+		}
+		rc.Close()
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/ch001.xhtml", "OEBPS/images/001.png"} {
+		if !files[name] {
+			t.Errorf("epub missing %s", name)
+		}
+	}
+
+	page := &bytes.Buffer{}
+	if err := b.WriteHTML(page); err != nil {
+		t.Fatal(err)
+	}
+	s := page.String()
+	for _, want := range []string{`<section class="chapter" id="ch002">`, `href="#ch002"`, `href="#ch002-L2"`, `id="ch002-L2"`, `href="#ch001-%E5%BF%AB%E6%8E%92"`, `src="data:image/png;base64,`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	if strings.Contains(s, "secret") || strings.Contains(s, ".xhtml") {
+		t.Errorf("html contains hidden note or epub links")
+	}
+}
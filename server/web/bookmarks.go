@@ -0,0 +1,11 @@
+package web
+
+import "node/pkg/bookmarks"
+
+// BookmarksData bookmarks.html 的数据：首页右侧默认显示的书签
+type BookmarksData struct {
+	Site       *Site
+	Categories []bookmarks.Category
+	Status     map[string]bookmarks.Status // 按 URL 的检查结果，未开启检查时为空
+	Admin      bool                        // 显示添加、删除书签的表单
+}
@@ -0,0 +1,169 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// epubFuncs OPF 和 NCX 是 XML，使用 text/template 并显式转义
+var epubFuncs = template.FuncMap{
+	"xml": func(s string) string {
+		buf := &strings.Builder{}
+		xml.EscapeText(buf, []byte(s))
+		return buf.String()
+	},
+	"inc": func(i int) int { return i + 1 },
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+var opfTpl = template.Must(template.New("content.opf").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="zh-CN">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.ID}}</dc:identifier>
+    <dc:title>{{xml .Book.Title}}</dc:title>
+    <dc:language>zh-CN</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="css" href="book.css" media-type="text/css"/>
+{{- range .Book.Chapters}}
+    <item id="{{.ID}}" href="{{.File}}" media-type="application/xhtml+xml"/>
+{{- end}}
+{{- range $i, $img := .Book.Images}}
+    <item id="img{{inc $i}}" href="{{$img.File}}" media-type="{{$img.Type}}"/>
+{{- end}}
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="nav"/>
+{{- range .Book.Chapters}}
+    <itemref idref="{{.ID}}"/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+// ncxTpl EPUB 2 阅读器使用的目录
+var ncxTpl = template.Must(template.New("toc.ncx").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="{{.ID}}"/>
+  </head>
+  <docTitle><text>{{xml .Book.Title}}</text></docTitle>
+  <navMap>
+{{- range $i, $c := .Book.Chapters}}
+    <navPoint id="nav-{{$c.ID}}" playOrder="{{inc $i}}">
+      <navLabel><text>{{xml $c.Title}}</text></navLabel>
+      <content src="{{$c.File}}"/>
+    </navPoint>
+{{- end}}
+  </navMap>
+</ncx>
+`))
+
+type epubData struct {
+	Book     *Book
+	ID       string
+	Modified string
+}
+
+// WriteEPUB 输出 EPUB 3 电子书（兼容 EPUB 2 阅读器的 toc.ncx）：目录页 nav.xhtml，每篇笔记一章，
+// 图片打包在 images/ 下；内容相同时输出的字节也相同
+func (b *Book) WriteEPUB(w io.Writer) error {
+	nav, err := Parse("book-nav.xhtml")
+	if err != nil {
+		return err
+	}
+	chapter, err := Parse("book-chapter.xhtml")
+	if err != nil {
+		return err
+	}
+	css, err := Assets.ReadFile("static/book.css")
+	if err != nil {
+		return err
+	}
+	sum := sha1.Sum([]byte(b.Dir + "\x00" + b.Title))
+	data := &epubData{
+		Book:     b,
+		ID:       fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]),
+		Modified: b.Modified.UTC().Format(time.RFC3339),
+	}
+
+	z := &epubWriter{zw: zip.NewWriter(w), modified: b.Modified}
+	// mimetype 必须是第一个文件且不压缩
+	z.add("mimetype", zip.Store, []byte("application/epub+zip"))
+	z.add("META-INF/container.xml", zip.Deflate, []byte(containerXML))
+	z.execute("OEBPS/content.opf", opfTpl, data)
+	z.execute("OEBPS/toc.ncx", ncxTpl, data)
+	z.execute("OEBPS/nav.xhtml", xhtml{nav}, b)
+	z.add("OEBPS/book.css", zip.Deflate, css)
+	for _, c := range b.Chapters {
+		z.execute("OEBPS/"+c.File, xhtml{chapter}, c)
+	}
+	for _, img := range b.Images {
+		// 图片本身已经压缩
+		z.add("OEBPS/"+img.File, zip.Store, img.Data)
+	}
+	if z.err != nil {
+		return z.err
+	}
+	return z.zw.Close()
+}
+
+// xhtml html/template 会转义模板开头的 XML 声明，改为在执行模板前写入
+type xhtml struct {
+	tpl interface{ Execute(io.Writer, any) error }
+}
+
+func (x xhtml) Execute(w io.Writer, data any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return x.tpl.Execute(w, data)
+}
+
+// epubWriter 记录第一个错误，之后的写入直接跳过
+type epubWriter struct {
+	zw       *zip.Writer
+	modified time.Time
+	err      error
+}
+
+func (z *epubWriter) add(name string, method uint16, b []byte) {
+	if z.err != nil {
+		return
+	}
+	f, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: z.modified})
+	if err != nil {
+		z.err = err
+		return
+	}
+	_, z.err = f.Write(b)
+}
+
+func (z *epubWriter) execute(name string, tpl interface{ Execute(io.Writer, any) error }, data any) {
+	if z.err != nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		z.err = fmt.Errorf("execute %s: %w", name, err)
+		return
+	}
+	z.add(name, zip.Deflate, buf.Bytes())
+}
@@ -0,0 +1,286 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"node/pkg/bookmarks"
+	"node/pkg/notemeta"
+	"node/pkg/notestore"
+	"node/pkg/wikilink"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExportOptions 静态导出选项
+type ExportOptions struct {
+	Notes        string                   // 笔记目录
+	Mounts       []notestore.MountOptions // 多个挂载点，非空时忽略 Notes；隐藏的挂载点不导出
+	Out          string                   // 输出目录
+	Store        *notestore.Options       // 为空时使用 StoreOptions
+	MaxIndexSize int                      // 超过该大小的文件不写入搜索索引，0 表示 1MB
+	Bookmarks    string                   // 书签文件，为空或不存在时书签页为空
+}
+
+// ExportResult 导出统计
+type ExportResult struct {
+	Notes       int
+	Tags        int
+	Attachments int
+}
+
+// SearchDoc search-index.json 中的一条记录，静态站点在浏览器里搜索
+type SearchDoc struct {
+	Path    string   `json:"path"`
+	URL     string   `json:"url"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+	Summary string   `json:"summary,omitempty"`
+	Text    string   `json:"text"`
+}
+
+type exporter struct {
+	opt   ExportOptions
+	store *notestore.Multi
+	site  *Site
+	tpl   map[string]*template.Template
+
+	files []string
+	raw   []string // 图片等附件，原样复制到 raw/
+	metas map[string]notemeta.Meta
+	links map[string][]wikilink.Link
+	docs  []SearchDoc
+}
+
+// Export 用服务端相同的模板把整个笔记目录渲染为静态站点，链接全部使用相对路径：
+//
+//	index.html          首页目录树
+//	bookmarks.html      书签
+//	notes/<path>.html   笔记
+//	raw/<path>          图片、PDF 等附件
+//	tags/               标签页
+//	static/             静态资源
+//	search-index.json   搜索索引
+func Export(opt ExportOptions) (*ExportResult, error) {
+	if opt.MaxIndexSize <= 0 {
+		opt.MaxIndexSize = 1 << 20
+	}
+	if opt.Store == nil {
+		opt.Store = &StoreOptions
+	}
+	if len(opt.Mounts) == 0 {
+		opt.Mounts = []notestore.MountOptions{{Dir: opt.Notes}}
+	}
+	store, err := notestore.OpenMulti(opt.Mounts, *opt.Store)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	e := &exporter{
+		opt:   opt,
+		store: store,
+		site:  &Site{Static: true},
+		tpl:   make(map[string]*template.Template),
+		metas: make(map[string]notemeta.Meta),
+		links: make(map[string][]wikilink.Link),
+	}
+	for _, name := range []string{"home.html", "md.html", "view.html", "tags.html", "bookmarks.html"} {
+		if e.tpl[name], err = Parse(name); err != nil {
+			return nil, err
+		}
+	}
+
+	tree := e.walk("")
+	if err := e.execute("index.html", "home.html", &HomeData{Site: e.site, Tree: template.HTML(tree)}); err != nil {
+		return nil, err
+	}
+	if err := e.bookmarks(); err != nil {
+		return nil, err
+	}
+	if err := e.notes(); err != nil {
+		return nil, err
+	}
+	if err := e.attachments(); err != nil {
+		return nil, err
+	}
+	tags, err := e.tags()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.searchIndex(); err != nil {
+		return nil, err
+	}
+	if err := e.assets(); err != nil {
+		return nil, err
+	}
+	return &ExportResult{Notes: len(e.files), Tags: tags, Attachments: len(e.raw)}, nil
+}
+
+// walk 读取目录，收集元信息、链接和搜索内容，并渲染目录树；读取失败的文件不导出
+func (e *exporter) walk(dir string) string {
+	entries, err := e.store.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	w := &strings.Builder{}
+	for _, d := range entries {
+		p := d.Name()
+		if dir != "" {
+			p = dir + "/" + p
+		}
+		if d.IsDir() {
+			WriteDir(w, p, d.Name(), e.walk(p))
+			continue
+		}
+		if _, ok := AttachmentType(p); ok {
+			e.raw = append(e.raw, p)
+			WriteFile(w, e.site, p, d.Name(), notemeta.Meta{})
+			continue
+		}
+		b, _, err := e.store.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		meta, body := notemeta.Parse(p, b)
+		e.files = append(e.files, p)
+		e.metas[p] = meta
+		if links := wikilink.Extract(p, b); len(links) > 0 {
+			e.links[p] = links
+		}
+		if len(b) <= e.opt.MaxIndexSize && bytes.IndexByte(b, 0) < 0 {
+			e.docs = append(e.docs, SearchDoc{
+				Path:    p,
+				URL:     e.site.Note(p),
+				Title:   meta.Title,
+				Tags:    append([]string{}, meta.Tags...),
+				Summary: meta.Summary,
+				Text:    string(body),
+			})
+		}
+		WriteFile(w, e.site, p, d.Name(), meta)
+	}
+	return w.String()
+}
+
+// bookmarks 导出的书签页不显示检查结果和编辑表单
+func (e *exporter) bookmarks() error {
+	var categories []bookmarks.Category
+	if e.opt.Bookmarks != "" {
+		var err error
+		if categories, err = bookmarks.Load(e.opt.Bookmarks); err != nil {
+			return err
+		}
+	}
+	return e.execute("bookmarks.html", "bookmarks.html", &BookmarksData{Site: e.site, Categories: categories})
+}
+
+func (e *exporter) notes() error {
+	resolver := wikilink.NewResolver(e.files)
+	for _, p := range e.files {
+		b, _, err := e.store.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		page := NotePage(p)
+		site := e.site.At(page)
+		data, err := RenderNote(site, p, b, site.WikiLinks(resolver, p))
+		if err != nil {
+			return fmt.Errorf("render %s: %w", p, err)
+		}
+		for _, from := range wikilink.Backlinks(resolver, e.links, p) {
+			data.Backlinks = append(data.Backlinks, NoteRef{Path: from, Title: e.metas[from].Title})
+		}
+		name := "view.html"
+		if data.IsMarkdown {
+			name = "md.html"
+		}
+		if err := e.execute(filepath.FromSlash(p)+".html", name, data, "notes"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachments 复制笔记引用的图片等附件
+func (e *exporter) attachments() error {
+	for _, p := range e.raw {
+		b, _, err := e.store.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(e.opt.Out, "raw", filepath.FromSlash(p)), bytes.NewReader(b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) tags() (int, error) {
+	tags := CountTags(e.metas)
+	site := e.site.At("tags/index.html")
+	if err := e.execute("index.html", "tags.html", &TagsData{Site: site, Tags: tags}, "tags"); err != nil {
+		return 0, err
+	}
+	for _, t := range tags {
+		data := &TagsData{Site: site, Tag: t.Tag, Notes: Tagged(e.metas, t.Tag)}
+		if err := e.execute(TagFile(t.Tag), "tags.html", data, "tags"); err != nil {
+			return 0, err
+		}
+	}
+	return len(tags), nil
+}
+
+func (e *exporter) searchIndex() error {
+	if e.docs == nil {
+		e.docs = []SearchDoc{}
+	}
+	b, err := json.Marshal(e.docs)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(e.opt.Out, "search-index.json"), bytes.NewReader(b))
+}
+
+// assets 复制内嵌的 static 目录
+func (e *exporter) assets() error {
+	return fs.WalkDir(Assets, "static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := Assets.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFile(filepath.Join(e.opt.Out, filepath.FromSlash(p)), f)
+	})
+}
+
+// execute 渲染模板并写入输出目录下的 dir/name
+func (e *exporter) execute(name, tplName string, data any, dir ...string) error {
+	buf := &bytes.Buffer{}
+	if err := e.tpl[tplName].Execute(buf, data); err != nil {
+		return fmt.Errorf("execute %s: %w", tplName, err)
+	}
+	return writeFile(filepath.Join(append(append([]string{e.opt.Out}, dir...), name)...), buf)
+}
+
+func writeFile(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"encoding/json"
+	"node/pkg/notestore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	notes := t.TempDir()
+	out := filepath.Join(t.TempDir(), "site")
+	os.MkdirAll(filepath.Join(notes, "Golang", "yingyong"), 0o755)
+	os.WriteFile(filepath.Join(notes, "readme.md"), []byte("---\ntitle: 首页\ntags: [go]\n---\n见 [[Golang/yingyong/context.go]] 和 [[missing]]\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "Golang", "yingyong", "context.go"), []byte("// Context 用法\n// tags: go\npackage yingyong\n"), 0o644)
+
+	marks := filepath.Join(t.TempDir(), "bookmarks.toml")
+	os.WriteFile(marks, []byte("[[categories]]\nname = '语言'\n[[categories.links]]\nname = 'Golang'\nurl = 'https://go.dev/'\n"), 0o644)
+
+	res, err := Export(ExportOptions{Notes: notes, Out: out, Bookmarks: marks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Notes != 2 || res.Tags != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(b)
+		for _, bad := range []string{`href="/`, `src="/`, "EventSource"} {
+			if strings.Contains(s, bad) {
+				t.Errorf("%s contains %q", name, bad)
+			}
+		}
+		return s
+	}
+
+	home := read("index.html")
+	for _, want := range []string{`href="notes/Golang/yingyong/context.go.html"`, `📄</small> 首页</a>`, `href="tags/go.html"`, `src="bookmarks.html"`} {
+		if !strings.Contains(home, want) {
+			t.Errorf("index.html missing %q", want)
+		}
+	}
+
+	readme := read("notes/readme.md.html")
+	if !strings.Contains(readme, `<a class="wikilink" href="../notes/Golang/yingyong/context.go.html">`) ||
+		!strings.Contains(readme, `<a class="wikilink broken" title="笔记不存在" href="#">missing</a>`) {
+		t.Errorf("wiki links not relative:\n%s", readme)
+	}
+	code := read("notes/Golang/yingyong/context.go.html")
+	if !strings.Contains(code, `<a href="../../../notes/readme.md.html">首页</a>`) {
+		t.Errorf("backlink not relative:\n%s", code)
+	}
+	if tag := read("tags/go.html"); !strings.Contains(tag, `href="../notes/readme.md.html"`) {
+		t.Errorf("tag page:\n%s", tag)
+	}
+	if b := read("bookmarks.html"); !strings.Contains(b, `<h4>语言</h4>`) || !strings.Contains(b, `href="https://go.dev/"`) || strings.Contains(b, "<form") {
+		t.Errorf("bookmarks page:\n%s", b)
+	}
+
+	var docs []SearchDoc
+	if err := json.Unmarshal([]byte(read("search-index.json")), &docs); err != nil || len(docs) != 2 {
+		t.Fatalf("search index: %v %+v", err, docs)
+	}
+	if docs[0].URL != "notes/Golang/yingyong/context.go.html" || docs[1].Title != "首页" || strings.Contains(docs[1].Text, "---") {
+		t.Errorf("unexpected docs: %+v", docs)
+	}
+}
+
+func TestExportMounts(t *testing.T) {
+	base := t.TempDir()
+	out := filepath.Join(t.TempDir(), "site")
+	for _, name := range []string{"work", "private"} {
+		os.MkdirAll(filepath.Join(base, name, "sub"), 0o755)
+		os.WriteFile(filepath.Join(base, name, "sub", "a.md"), []byte("# "+name+"\n[[b]]\n"), 0o644)
+		os.WriteFile(filepath.Join(base, name, "b.md"), []byte("# b\n"), 0o644)
+	}
+
+	res, err := Export(ExportOptions{Out: out, Mounts: []notestore.MountOptions{
+		{Name: "work", Dir: filepath.Join(base, "work")},
+		{Name: "private", Dir: filepath.Join(base, "private"), Hidden: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Notes != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	b, err := os.ReadFile(filepath.Join(out, "notes", "work", "sub", "a.md.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `href="../../../notes/work/b.md.html"`) {
+		t.Errorf("mount-relative wiki link not resolved:\n%s", b)
+	}
+	if _, err := os.Stat(filepath.Join(out, "notes", "private")); !os.IsNotExist(err) {
+		t.Errorf("hidden mount exported: %v", err)
+	}
+}
+
+func TestExportAttachments(t *testing.T) {
+	notes := t.TempDir()
+	out := filepath.Join(t.TempDir(), "site")
+	os.MkdirAll(filepath.Join(notes, "Golang", "img"), 0o755)
+	os.WriteFile(filepath.Join(notes, "Golang", "a.md"), []byte("![图](img/gc%201.png) [论文](../paper.pdf) ![外链](https://example.com/x.png)\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "Golang", "img", "gc 1.png"), []byte("\x89PNG\r\n\x1a\n"), 0o644)
+	os.WriteFile(filepath.Join(notes, "paper.pdf"), []byte("%PDF-1.4\n"), 0o644)
+
+	res, err := Export(ExportOptions{Notes: notes, Out: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Notes != 1 || res.Attachments != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	b, err := os.ReadFile(filepath.Join(out, "notes", "Golang", "a.md.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`src="../../raw/Golang/img/gc%201.png"`, `href="../../raw/paper.pdf"`, `src="https://example.com/x.png"`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("a.md.html missing %q", want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "raw", "Golang", "img", "gc 1.png")); err != nil {
+		t.Error(err)
+	}
+	home, _ := os.ReadFile(filepath.Join(out, "index.html"))
+	if !strings.Contains(string(home), `href="raw/paper.pdf"`) {
+		t.Errorf("tree does not link attachment:\n%s", home)
+	}
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"html/template"
+	"node/pkg/highlight"
+	"node/pkg/markdown"
+	"node/pkg/notemeta"
+	"node/pkg/wikilink"
+	"strings"
+)
+
+// NoteRef 笔记列表项
+type NoteRef struct {
+	Path  string `json:"path"`
+	Title string `json:"title,omitempty"`
+}
+
+// HomeData home.html 的数据
+type HomeData struct {
+	Site  *Site
+	Tree  template.HTML
+	Login bool   // 是否可以登录，仅服务端
+	User  string // 已登录的用户名
+}
+
+// ViewData md.html 和 view.html 的数据
+type ViewData struct {
+	Site       *Site
+	Title      string
+	Nav        string
+	Content    string
+	IsMarkdown bool
+	HTML       template.HTML
+	TOC        []markdown.Heading
+	Code       template.HTML
+	Meta       notemeta.Meta
+	Backlinks  []NoteRef
+	Run, Test  bool // Go 笔记可以在沙箱中运行或测试，仅服务端
+}
+
+// RenderNote Markdown 渲染为 HTML 和目录，front matter 不参与渲染；其他文件做语法高亮，带行号和 #L 锚点。
+// 两者的 [[ ]] 链接都通过 link 生成地址，Markdown 中图片和附件的相对地址指向笔记旁边的原始文件
+func RenderNote(site *Site, p string, b []byte, link markdown.LinkResolver) (*ViewData, error) {
+	meta, body := notemeta.Parse(p, b)
+	data := &ViewData{
+		Site:       site,
+		Title:      p,
+		Nav:        strings.ReplaceAll(p, "/", "📌"),
+		IsMarkdown: strings.HasSuffix(p, ".md"),
+		Meta:       meta,
+	}
+	if data.IsMarkdown {
+		doc, err := markdown.Render(body, markdown.WithLinkResolver(link), markdown.WithAssetResolver(site.Attachments(p)))
+		if err != nil {
+			return nil, err
+		}
+		data.HTML = doc.HTML
+		data.TOC = doc.TOC
+	} else {
+		data.Content = string(b)
+		data.Code = template.HTML(wikilink.Linkify(string(highlight.Render(highlight.Lang(p), data.Content)), link))
+	}
+	return data, nil
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"encoding/xml"
+	"io"
+	"node/pkg/notemeta"
+	"sort"
+	"time"
+)
+
+// RecentNote 最近新增或修改的笔记
+type RecentNote struct {
+	Path    string
+	Meta    notemeta.Meta
+	ModTime time.Time
+	Added   bool // 服务运行期间新增的笔记，启动前已有的笔记无法区分，按修改处理
+}
+
+// RecentData recent.html 的数据
+type RecentData struct {
+	Site  *Site
+	Notes []RecentNote
+}
+
+// Recent 按修改时间倒序取前 n 个，时间相同时按路径排序
+func Recent(notes []RecentNote, n int) []RecentNote {
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if !a.ModTime.Equal(b.ModTime) {
+			return a.ModTime.After(b.ModTime)
+		}
+		return a.Path < b.Path
+	})
+	if len(notes) > n {
+		notes = notes[:n]
+	}
+	return notes
+}
+
+// Feed Atom 订阅，地址都是绝对地址
+type Feed struct {
+	Title string
+	Base  string // 站点地址，如 "https://notes.example.com"，不以 / 结尾
+	Self  string // 订阅自身的路径，如 "/recent.atom"
+	Notes []RecentNote
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// WriteAtom 输出 Atom 1.0 订阅。条目 id 为笔记地址，修改后 updated 变化，阅读器据此显示更新
+func WriteAtom(w io.Writer, f *Feed) error {
+	out := &atomFeed{
+		Title:  f.Title,
+		ID:     f.Base + f.Self,
+		Links:  []atomLink{{Rel: "self", Href: f.Base + f.Self}, {Href: f.Base + Server.Home()}},
+		Author: atomAuthor{Name: f.Title},
+	}
+	var updated time.Time
+	for _, n := range f.Notes {
+		if n.ModTime.After(updated) {
+			updated = n.ModTime
+		}
+		href := f.Base + Server.Note(n.Path)
+		title := n.Meta.Title
+		if title == "" {
+			title = n.Path
+		}
+		if n.Added {
+			title = "[新增] " + title
+		}
+		e := atomEntry{
+			Title:   title,
+			ID:      href,
+			Updated: n.ModTime.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: href},
+			Summary: n.Meta.Summary,
+		}
+		for _, t := range n.Meta.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		out.Entries = append(out.Entries, e)
+	}
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	out.Updated = updated.UTC().Format(time.RFC3339)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(out)
+}
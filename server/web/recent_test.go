@@ -0,0 +1,45 @@
+package web
+
+import (
+	"bytes"
+	"encoding/xml"
+	"node/pkg/notemeta"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecent(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	notes := Recent([]RecentNote{
+		{Path: "a.md", ModTime: day},
+		{Path: "c.md", ModTime: day.Add(time.Hour)},
+		{Path: "b.md", ModTime: day},
+	}, 2)
+	if len(notes) != 2 || notes[0].Path != "c.md" || notes[1].Path != "a.md" {
+		t.Fatalf("unexpected order: %+v", notes)
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteAtom(buf, &Feed{Title: "笔记更新", Base: "http://notes.local", Self: "/recent.atom", Notes: []RecentNote{
+		{Path: "Go/a.md", Meta: notemeta.Meta{Title: "A <b>", Summary: "摘要", Tags: []string{"go"}}, ModTime: day, Added: true},
+		{Path: "b.go", ModTime: day.Add(-time.Hour)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid atom: %v\n%s", err, buf)
+	}
+	if feed.Updated != "2026-01-02T00:00:00Z" || len(feed.Entries) != 2 || feed.Links[0].Href != "http://notes.local/recent.atom" {
+		t.Fatalf("unexpected feed: %+v", feed)
+	}
+	e := feed.Entries[0]
+	if e.Title != "[新增] A <b>" || e.ID != "http://notes.local/view/Go%2Fa.md" || e.Summary != "摘要" || e.Categories[0].Term != "go" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if feed.Entries[1].Title != "b.go" || !strings.Contains(buf.String(), `xmlns="http://www.w3.org/2005/Atom"`) {
+		t.Fatalf("unexpected feed:\n%s", buf)
+	}
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"net/url"
+	"node/pkg/markdown"
+	"node/pkg/wikilink"
+	"path"
+	"strings"
+)
+
+// Site 生成页面之间的链接：服务端使用路由地址，静态导出使用相对路径
+type Site struct {
+	Static bool
+	Root   string // 静态导出时当前页面到站点根目录的相对路径，如 "../../"
+	Edit   bool   // 是否可在线编辑，决定失效链接是否指向编辑页
+}
+
+// Server 服务端使用的 Site
+var Server = &Site{}
+
+// At 返回站点中路径为 page 的页面使用的 Site，如 "notes/Golang/a.md.html"
+func (s *Site) At(page string) *Site {
+	if !s.Static {
+		return s
+	}
+	c := *s
+	c.Root = strings.Repeat("../", strings.Count(page, "/"))
+	return &c
+}
+
+func (s *Site) Home() string {
+	if !s.Static {
+		return "/"
+	}
+	return s.Root + "index.html"
+}
+
+func (s *Site) Note(p string) string {
+	if !s.Static {
+		return "/view/" + url.PathEscape(p)
+	}
+	return s.Root + NotePage(p)
+}
+
+func (s *Site) Tags() string {
+	if !s.Static {
+		return "/tags"
+	}
+	return s.Root + "tags/index.html"
+}
+
+func (s *Site) Tag(t string) string {
+	if !s.Static {
+		return "/tags/" + url.PathEscape(t)
+	}
+	return s.Root + "tags/" + url.PathEscape(TagFile(t))
+}
+
+// Bookmarks 书签页，首页右侧默认显示
+func (s *Site) Bookmarks() string {
+	if !s.Static {
+		return "/bookmarks"
+	}
+	return s.Root + "bookmarks.html"
+}
+
+// Asset static 目录下的文件
+func (s *Site) Asset(name string) string {
+	if !s.Static {
+		return "/static/" + name
+	}
+	return s.Root + "static/" + name
+}
+
+// Raw 笔记目录中的原始文件，如图片、PDF
+func (s *Site) Raw(p string) string {
+	if !s.Static {
+		return "/raw/" + escapePath(p)
+	}
+	return s.Root + "raw/" + escapePath(p)
+}
+
+// Attachments 返回笔记 from 中图片和附件相对地址的改写函数：相对于笔记所在目录解析，指向 Raw；
+// 带协议、以 / 开头或超出笔记根目录的地址保持不变，普通链接只改写附件类型的文件
+func (s *Site) Attachments(from string) markdown.AssetResolver {
+	dir := path.Dir(from)
+	return func(dest string, image bool) (string, bool) {
+		u, err := url.Parse(dest)
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+			return "", false
+		}
+		p := path.Join(dir, u.Path)
+		if p == ".." || strings.HasPrefix(p, "../") {
+			return "", false
+		}
+		if _, ok := AttachmentType(p); !ok && !image {
+			return "", false
+		}
+		href := s.Raw(p)
+		if u.Fragment != "" {
+			href += "#" + u.EscapedFragment()
+		}
+		return href, true
+	}
+}
+
+// WikiLinks 返回笔记 from 中 [[ ]] 链接的地址生成函数
+func (s *Site) WikiLinks(r *wikilink.Resolver, from string) markdown.LinkResolver {
+	return func(l wikilink.Link) (string, bool) {
+		p, ok := r.Resolve(from, l.Target)
+		if !ok {
+			if s.Edit {
+				return "/edit/" + url.PathEscape(l.Target), false
+			}
+			return "#", false
+		}
+		href := s.Note(p)
+		if l.Anchor != "" {
+			href += "#" + url.PathEscape(markdown.Slug(l.Anchor))
+		}
+		return href, true
+	}
+}
+
+// NotePage 静态站点中笔记页面的地址
+func NotePage(p string) string {
+	return escapePath("notes/" + p + ".html")
+}
+
+// escapePath 逐段转义，保留路径中的 /，页面中的相对地址才能正确解析
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
+
+// attachmentTypes 作为附件直接输出的文件类型，不按文本渲染
+var attachmentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".avif": "image/avif",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+	".bmp":  "image/bmp",
+	".pdf":  "application/pdf",
+	".mp3":  "audio/mpeg",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".zip":  "application/zip",
+}
+
+// AttachmentType 按扩展名判断是否为附件，返回其 Content-Type
+func AttachmentType(p string) (string, bool) {
+	t, ok := attachmentTypes[strings.ToLower(path.Ext(p))]
+	return t, ok
+}
+
+// TagFile 静态站点中标签页的文件名，标签中的 / 不能出现在文件名里
+func TagFile(t string) string {
+	return strings.ReplaceAll(t, "/", "_") + ".html"
+}
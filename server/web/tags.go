@@ -0,0 +1,66 @@
+package web
+
+import (
+	"node/pkg/notemeta"
+	"sort"
+)
+
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+type TagNote struct {
+	Path string
+	Meta notemeta.Meta
+}
+
+// TagsData tags.html 的数据，Tag 为空时列出全部标签
+type TagsData struct {
+	Site  *Site
+	Tag   string
+	Tags  []TagCount
+	Notes []TagNote
+}
+
+// CountTags 所有标签，按笔记数量倒序
+func CountTags(metas map[string]notemeta.Meta) []TagCount {
+	counts := make(map[string]int)
+	for _, m := range metas {
+		for _, t := range m.Tags {
+			counts[t]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		tags = append(tags, TagCount{Tag: t, Count: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+// Tagged 带有标签 tag 的笔记，按日期倒序
+func Tagged(metas map[string]notemeta.Meta, tag string) []TagNote {
+	var notes []TagNote
+	for p, m := range metas {
+		for _, t := range m.Tags {
+			if t == tag {
+				notes = append(notes, TagNote{Path: p, Meta: m})
+				break
+			}
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if !a.Meta.Date.Equal(b.Meta.Date) {
+			return a.Meta.Date.After(b.Meta.Date)
+		}
+		return a.Path < b.Path
+	})
+	return notes
+}
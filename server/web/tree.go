@@ -0,0 +1,45 @@
+package web
+
+import (
+	"html"
+	"node/pkg/notemeta"
+	"strings"
+)
+
+// WriteDir 目录树中的目录，children 为子目录渲染好的 <li> 列表
+func WriteDir(w *strings.Builder, p, name, children string) {
+	w.WriteString(`<li><span class="dir" data-path="`)
+	w.WriteString(html.EscapeString(p))
+	w.WriteString(`"><span>📘</span> `)
+	w.WriteString(html.EscapeString(name))
+	w.WriteString(`</span><ul class="sub-ul">`)
+	w.WriteString(children)
+	w.WriteString("</ul></li>\n")
+}
+
+// WriteFile 目录树中的文件，有标题时显示标题，文件名放在 title 提示里；附件直接链接到原始文件
+func WriteFile(w *strings.Builder, site *Site, p, name string, meta notemeta.Meta) {
+	text := name
+	if meta.Title != "" {
+		text = meta.Title
+	}
+	href := site.Note(p)
+	if _, ok := AttachmentType(p); ok {
+		href = site.Raw(p)
+	}
+	w.WriteString(`<li><a class="file" href="`)
+	w.WriteString(html.EscapeString(href))
+	w.WriteString(`" title="`)
+	w.WriteString(html.EscapeString(name))
+	w.WriteString(`"><small>📄</small> `)
+	w.WriteString(html.EscapeString(text))
+	w.WriteString("</a>")
+	for _, t := range meta.Tags {
+		w.WriteString(` <a class="tag" href="`)
+		w.WriteString(html.EscapeString(site.Tag(t)))
+		w.WriteString(`">#`)
+		w.WriteString(html.EscapeString(t))
+		w.WriteString("</a>")
+	}
+	w.WriteString("</li>\n")
+}
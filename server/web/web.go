@@ -0,0 +1,39 @@
+// Package web 页面模板、静态资源以及笔记页面的渲染，服务端和静态导出共用
+package web
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/url"
+	"node/pkg/notestore"
+)
+
+//go:embed tpl
+var tpl embed.FS
+
+// Assets static 目录下的静态资源，路径带 static/ 前缀
+//
+//go:embed static
+var Assets embed.FS
+
+// Funcs 模板函数
+var Funcs = template.FuncMap{
+	"pathEscape": url.PathEscape,
+}
+
+// StoreOptions 笔记读取策略，服务端和静态导出一致：不读取证书私钥等敏感文件
+var StoreOptions = notestore.Options{
+	Symlinks: notestore.SymlinkInRoot,
+	DenyExt:  []string{".pem", ".key", ".crt", ".p12"},
+	MaxSize:  10 << 20,
+}
+
+// Parse 解析 tpl 目录下的模板
+func Parse(name string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(Funcs).ParseFS(tpl, "tpl/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return t, nil
+}